@@ -0,0 +1,80 @@
+package jf
+
+import (
+	"regexp"
+
+	"github.com/stretchr/objx"
+)
+
+// AddArrayKey matches elements of arrays of objects by the value of key
+// instead of their index, so [{"id": 1}, {"id": 2}] elements are paired by
+// "id". Differences of paired elements are reported with the index in jsonA
+// and positions of both elements, elements without a pair are reported as removed or added.
+func (d *Differ) AddArrayKey(selector *regexp.Regexp, key string) *Differ {
+	return d.addRule(RuleAB, &rule{selector: selector, action: arrayKey, key: key})
+}
+
+func (d *Differ) arrayKey(selector string) (string, bool) {
+	for _, rule := range d.rulesA {
+		if rule.action == arrayKey && rule.match(selector) {
+			return rule.key, true
+		}
+	}
+	return "", false
+}
+
+// elementKey returns JSON of the key of array element
+func elementKey(i interface{}, key string) (string, bool) {
+	m, ok := i.(objx.Map)
+	if !ok {
+		return "", false
+	}
+	value, found := m[key]
+	if !found {
+		return "", false
+	}
+	return toJSONText(value), true
+}
+
+// diffSliceByKey diffs arrays, where elements are paired by a key
+func (d *Differ) diffSliceByKey(p path, sliceA, sliceB []interface{}, key string) error {
+	indexB := make(map[string]int, len(sliceB))
+	for idx, b := range sliceB {
+		if k, ok := elementKey(b, key); ok {
+			if _, found := indexB[k]; !found {
+				indexB[k] = idx
+			}
+		}
+	}
+
+	matchedB := newIntSet()
+	for idx, a := range sliceA {
+		if d.full() {
+			return errMaxDiffs
+		}
+		item := p.index(idx)
+		if k, ok := elementKey(a, key); ok {
+			if idxB, found := indexB[k]; found && !matchedB.Has(idxB) {
+				matchedB.Add(idxB)
+				err := d.diffValues(p.indexAB(idx, idxB), newValue(a), newValue(sliceB[idxB]))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		d.lineA(item, jsonI{i: a, floatEqualFunc: d.floatEqualFunc(item.selector)})
+	}
+
+	for idx, b := range sliceB {
+		if matchedB.Has(idx) {
+			continue
+		}
+		if d.full() {
+			return errMaxDiffs
+		}
+		item := p.index(idx)
+		d.lineB(item, jsonI{i: b, floatEqualFunc: d.floatEqualFunc(item.selector)})
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package jf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrayKey(t *testing.T) {
+	const jsonA = `{"users": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3}, {"name": "nokey"}]}`
+	const jsonB = `{"users": [{"id": 2, "name": "B"}, {"id": 4}, {"id": 1, "name": "a"}]}`
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().AddArrayKey(re(t, `^users$`), "id").Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 4)
+	assertLine(t, lines[0], "users[1].name", `"b"`, `"B"`)
+	assertLine(t, lines[1], "users[2]", `{"id":3}`, "")
+	assertLine(t, lines[2], "users[3]", `{"name":"nokey"}`, "")
+	assertLine(t, lines[3], "users[1]", "", `{"id":4}`)
+	assert.Equal(KindAdded, lines[3].Kind())
+
+	lines, err = NewDiffer().AddArrayKey(re(t, `^users$`), "id").Diff(
+		`{"users": [{"id": "x", "v": 1}, {"id": "y", "v": 2}]}`,
+		`{"users": [{"id": "y", "v": 2}, {"id": "x", "v": 1}]}`)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+}
+
+// TestArrayKeyPositions tests positions of paired elements point to the
+// element of each input
+func TestArrayKeyPositions(t *testing.T) {
+	const jsonA = `{"items": [
+  {"id": 1, "v": 1},
+  {"id": 2, "v": 2}
+]}`
+	const jsonB = `{"items": [
+  {"id": 2, "v": 3},
+  {"id": 1, "v": 1}
+]}`
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().AddArrayKey(re(t, `^items$`), "id").Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "items[1].v", "2", "3")
+	assert.Equal(Position{Offset: 50, Line: 3, Column: 18}, lines[0].PosA())
+	assert.Equal(Position{Offset: 29, Line: 2, Column: 18}, lines[0].PosB())
+	assert.Equal("/items/1/v", lines[0].PointerA())
+	assert.Equal("/items/0/v", lines[0].PointerB())
+}
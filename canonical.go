@@ -0,0 +1,125 @@
+package jf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/stretchr/objx"
+)
+
+// strip removes map keys ignored by rules of jsonA (isA) or jsonB. Array
+// items are never removed, as this would change indexes of other items.
+func (d *Differ) strip(selector string, i interface{}, isA bool) interface{} {
+	switch v := i.(type) {
+	case objx.Map:
+		m := make(objx.Map, len(v))
+		for key, value := range v {
+			keySelector := joinSelectors(selector, key)
+			if d.shouldStrip(keySelector, value, isA) {
+				continue
+			}
+			m[key] = d.strip(keySelector, value, isA)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for idx, value := range v {
+			s[idx] = d.strip(joinSelectors(selector, fmt.Sprintf("[%d]", idx)), value, isA)
+		}
+		return s
+	}
+	return i
+}
+
+func (d *Differ) shouldStrip(selector string, i interface{}, isA bool) bool {
+	ignoreA, ignoreB := d.shouldIgnore(selector)
+	ignoreIfZeroA, ignoreIfZeroB := d.shouldIgnoreIfZero(selector)
+	if isA {
+		return ignoreA || (ignoreIfZeroA && jsonI{i: newValue(i), floatEqualFunc: d.floatEqualFunc(selector)}.isZero())
+	}
+	return ignoreB || (ignoreIfZeroB && jsonI{i: newValue(i), floatEqualFunc: d.floatEqualFunc(selector)}.isZero())
+}
+
+// canonicalPair walks both values and replaces the value of B by value of A
+// everywhere Differ considers them equal. So values equal thanks to rules
+// like ignore order or null coercion looks the same.
+func (d *Differ) canonicalPair(p path, a, b interface{}) (interface{}, interface{}) {
+	other := d.clone().SetMaxDiffs(1)
+	err := other.diffValues(p, newValue(a), newValue(b))
+	if err == nil && len(other.diff) == 0 {
+		return a, a
+	}
+
+	mA, okA := a.(objx.Map)
+	mB, okB := b.(objx.Map)
+	if okA && okB {
+		retA := make(objx.Map, len(mA))
+		retB := make(objx.Map, len(mB))
+		for key, valueA := range mA {
+			valueB, found := mB[key]
+			if !found {
+				retA[key] = valueA
+				continue
+			}
+			retA[key], retB[key] = d.canonicalPair(p.key(key), valueA, valueB)
+		}
+		for key, valueB := range mB {
+			if _, found := mA[key]; !found {
+				retB[key] = valueB
+			}
+		}
+		return retA, retB
+	}
+
+	sA, okA := a.([]interface{})
+	sB, okB := b.([]interface{})
+	if okA && okB && !d.shouldIgnoreOrder(p.selector) {
+		retA := append([]interface{}{}, sA...)
+		retB := append([]interface{}{}, sB...)
+		for idx := 0; idx < len(sA) && idx < len(sB); idx++ {
+			retA[idx], retB[idx] = d.canonicalPair(p.index(idx), sA[idx], sB[idx])
+		}
+		return retA, retB
+	}
+
+	return a, b
+}
+
+func prettyJSON(i interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(i)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Canonical returns jsonA and jsonB pretty printed with sorted keys and the
+// rules applied. Keys ignored by rules are dropped and values Differ
+// considers equal are printed the same, so line based diff of results shows
+// the same differences as Diff.
+func (d *Differ) Canonical(jsonA, jsonB string) (string, string, error) {
+	docA, err := parseDocument(jsonA)
+	if err != nil {
+		return "", "", fmt.Errorf("jsonA: %w", err)
+	}
+	docB, err := parseDocument(jsonB)
+	if err != nil {
+		return "", "", fmt.Errorf("jsonB: %w", err)
+	}
+
+	a, b := d.canonicalPair(path{}, d.strip("", docA.root, true), d.strip("", docB.root, false))
+	prettyA, err := prettyJSON(a)
+	if err != nil {
+		return "", "", err
+	}
+	prettyB, err := prettyJSON(b)
+	if err != nil {
+		return "", "", err
+	}
+	return prettyA, prettyB, nil
+}
@@ -0,0 +1,82 @@
+package jf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCanonical tests pretty printing with rules applied
+func TestCanonical(t *testing.T) {
+	const jsonA = `{"list": [1, 2, 3], "id": 1, "time": "12:00", "null": null, "html": "<b>"}`
+	const jsonB = `{"time": "12:01", "id": 2, "null": 0, "list": [3, 2, 1], "html": "<b>"}`
+
+	const expectedA = `{
+  "html": "<b>",
+  "id": 1,
+  "list": [
+    1,
+    2,
+    3
+  ],
+  "null": null
+}
+`
+	const expectedB = `{
+  "html": "<b>",
+  "id": 2,
+  "list": [
+    1,
+    2,
+    3
+  ],
+  "null": null
+}
+`
+
+	assert := assert.New(t)
+	prettyA, prettyB, err := NewDiffer().
+		AddIgnoreOrder(re(t, "list")).
+		AddCoerceNull(RuleAB, re(t, "null")).
+		AddIgnore(RuleAB, re(t, "time")).
+		Canonical(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Equal(expectedA, prettyA)
+	assert.Equal(expectedB, prettyB)
+}
+
+// TestCanonicalNested tests equal parts of nested values are printed the same
+func TestCanonicalNested(t *testing.T) {
+	const jsonA = `{"data": [{"f": 1.0001, "s": "a"}], "extra": {"zero": 0}}`
+	const jsonB = `{"data": [{"f": 1, "s": "b"}, {}], "extra": {}}`
+
+	eq := func(a, b float64) bool { return a-b < 0.1 && b-a < 0.1 }
+
+	assert := assert.New(t)
+	prettyA, prettyB, err := NewDiffer().
+		AddFloatEqual(re(t, `\.f$`), eq).
+		AddIgnoreIfZero(RuleA, re(t, "zero")).
+		Canonical(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Equal(`{
+  "data": [
+    {
+      "f": 1.0001,
+      "s": "a"
+    }
+  ],
+  "extra": {}
+}
+`, prettyA)
+	assert.Equal(`{
+  "data": [
+    {
+      "f": 1.0001,
+      "s": "b"
+    },
+    {}
+  ],
+  "extra": {}
+}
+`, prettyB)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vyskocilm/jf"
+)
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// diffMessage describes one difference in a single line
+func diffMessage(p jf.SingleDiff) string {
+	switch p.Kind() {
+	case jf.KindAdded:
+		return fmt.Sprintf("%s: added %s", p.Selector(), p.B())
+	case jf.KindRemoved:
+		return fmt.Sprintf("%s: removed %s", p.Selector(), p.A())
+	case jf.KindDuplicateKey:
+		return fmt.Sprintf("%s: duplicate key %s%s", p.Selector(), p.A(), p.B())
+	case jf.KindSchema:
+		return fmt.Sprintf("%s: %s", p.Selector(), p.Message())
+	}
+	return fmt.Sprintf("%s: %s != %s", p.Selector(), p.A(), p.B())
+}
+
+// diffLocation returns path:line:column of a difference
+func diffLocation(r *report, p jf.SingleDiff) string {
+	if p.Kind() == jf.KindAdded || (p.Kind() == jf.KindDuplicateKey && p.A() == "") ||
+		(p.Kind() == jf.KindSchema && !p.PosA().IsValid()) {
+		return location(r.pathB, p.PosB())
+	}
+	return location(r.pathA, p.PosA())
+}
+
+// junitTestCases returns test cases of a pair of files, each difference is
+// a test case with one failure, so consumers show all of them. Test cases
+// of the pair share the class name.
+func junitTestCases(r *report) []junitTestCase {
+	pair := r.pathA + " " + r.pathB
+	if r.message() != "" {
+		return []junitTestCase{{
+			Name:      pair,
+			ClassName: pair,
+			Failure: &junitFailure{
+				Message: r.message(),
+				Type:    junitType(r),
+				Text:    r.message(),
+			},
+		}}
+	}
+	if len(r.diff) == 0 {
+		return []junitTestCase{{Name: pair, ClassName: pair}}
+	}
+	cases := make([]junitTestCase, len(r.diff))
+	for idx, p := range r.diff {
+		cases[idx] = junitTestCase{
+			Name:      p.Selector(),
+			ClassName: pair,
+			Failure: &junitFailure{
+				Message: diffMessage(p),
+				Type:    p.Kind().String(),
+				Text:    diffLocation(r, p) + ": " + diffMessage(p),
+			},
+		}
+	}
+	return cases
+}
+
+// writeJUnit prints JUnit XML, where each difference of a pair of files is
+// a failed test case
+func writeJUnit(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+	suite := junitTestSuite{
+		Name:      "jf",
+		TestCases: make([]junitTestCase, 0, len(reports)),
+	}
+	for _, r := range reports {
+		for _, tc := range junitTestCases(r) {
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+	}
+	suite.Tests = len(suite.TestCases)
+
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+// junitType returns failure type of a report without differences
+func junitType(r *report) string {
+	if r.problem != "" {
+		return "problem"
+	}
+	return "missing"
+}
+
+// yamlQuote returns single quoted YAML string
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// writeTAP prints Test Anything Protocol version 13, where each pair of files
+// is a test and differences are in YAML diagnostic block
+func writeTAP(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+	fmt.Fprintf(w, "TAP version 13\n")
+	fmt.Fprintf(w, "1..%d\n", len(reports))
+	for idx, r := range reports {
+		if !r.failed() {
+			fmt.Fprintf(w, "ok %d - %s %s\n", idx+1, r.pathA, r.pathB)
+			continue
+		}
+		fmt.Fprintf(w, "not ok %d - %s %s\n", idx+1, r.pathA, r.pathB)
+		if r.message() != "" {
+			fmt.Fprintf(w, "  ---\n")
+			fmt.Fprintf(w, "  message: %s\n", yamlQuote(r.message()))
+			fmt.Fprintf(w, "  ...\n")
+			continue
+		}
+		fmt.Fprintf(w, "  ---\n")
+		fmt.Fprintf(w, "  message: %s\n", yamlQuote(fmt.Sprintf("%d differences", len(r.diff))))
+		fmt.Fprintf(w, "  diffs:\n")
+		for _, p := range r.diff {
+			fmt.Fprintf(w, "    - selector: %s\n", yamlQuote(p.Selector()))
+			fmt.Fprintf(w, "      kind: %s\n", p.Kind())
+			fmt.Fprintf(w, "      at: %s\n", yamlQuote(diffLocation(r, p)))
+			if p.A() != "" {
+				fmt.Fprintf(w, "      a: %s\n", yamlQuote(p.A()))
+			}
+			if p.B() != "" {
+				fmt.Fprintf(w, "      b: %s\n", yamlQuote(p.B()))
+			}
+			if p.Message() != "" {
+				fmt.Fprintf(w, "      message: %s\n", yamlQuote(p.Message()))
+			}
+		}
+		fmt.Fprintf(w, "  ...\n")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vyskocilm/jf"
+)
+
+// testReports returns a report with differences, an equal one, a file on one
+// side only and a problem
+func testReports(t *testing.T) []*report {
+	t.Helper()
+	d := jf.NewDiffer()
+	differ, err := newReportFromJSON(d, "a.json", "b.json", `{"a": 1, "b": 2}`, `{"a": 2, "c": 3}`)
+	require.NoError(t, err)
+	equal, err := newReportFromJSON(d, "a2.json", "b2.json", `{"a": 1}`, `{"a": 1}`)
+	require.NoError(t, err)
+	return []*report{
+		differ,
+		equal,
+		{pathA: "a3.json", pathB: "b3.json", only: "A"},
+		{pathA: "a4.json", pathB: "b4.json", problem: "status 200 != 500"},
+	}
+}
+
+// TestWriteJUnit tests each difference is a failed test case
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeJUnit(&buf, jf.NewDiffer(), testReports(t), &options{format: "junit"}))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Len(t, suites.Suites, 1)
+	suite := suites.Suites[0]
+	assert.Equal(t, 6, suite.Tests)
+	assert.Equal(t, 5, suite.Failures)
+	require.Len(t, suite.TestCases, 6)
+
+	// a test case with a failure per difference
+	for idx, expected := range []struct {
+		name, kind, text string
+	}{
+		{"a", "changed", "a.json:1:7: a: 1 != 2"},
+		{"b", "removed", "a.json:1:15: b: removed 2"},
+		{"c", "added", "b.json:1:15: c: added 3"},
+	} {
+		tc := suite.TestCases[idx]
+		assert.Equal(t, expected.name, tc.Name)
+		assert.Equal(t, "a.json b.json", tc.ClassName)
+		require.NotNil(t, tc.Failure)
+		assert.Equal(t, expected.kind, tc.Failure.Type)
+		assert.Equal(t, expected.text, tc.Failure.Text)
+	}
+	assert.Equal(t, "a: 1 != 2", suite.TestCases[0].Failure.Message)
+	assert.Equal(t, 5, strings.Count(buf.String(), "<failure "))
+
+	assert.Equal(t, "a2.json b2.json", suite.TestCases[3].Name)
+	assert.Nil(t, suite.TestCases[3].Failure)
+	assert.Equal(t, "missing", suite.TestCases[4].Failure.Type)
+	assert.Equal(t, "only in a3.json", suite.TestCases[4].Failure.Message)
+	assert.Equal(t, "problem", suite.TestCases[5].Failure.Type)
+}
+
+func TestWriteTAP(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeTAP(&buf, jf.NewDiffer(), testReports(t), &options{format: "tap"}))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "TAP version 13\n1..4\n"))
+	assert.Contains(t, out, "not ok 1 - a.json b.json\n  ---\n  message: '3 differences'\n")
+	assert.Contains(t, out, "    - selector: 'b'\n      kind: removed\n      at: 'a.json:1:15'\n      a: '2'\n")
+	assert.Contains(t, out, "ok 2 - a2.json b2.json\n")
+	assert.Contains(t, out, "not ok 3 - a3.json b3.json\n  ---\n  message: 'only in a3.json'\n")
+	assert.Contains(t, out, "message: 'a4.json b4.json: status 200 != 500'")
+}
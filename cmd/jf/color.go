@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// colorizer wraps strings into ANSI escape sequences if enabled
+type colorizer bool
+
+func (c colorizer) wrap(code, s string) string {
+	if !c {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (c colorizer) bold(s string) string   { return c.wrap(ansiBold, s) }
+func (c colorizer) red(s string) string    { return c.wrap(ansiRed, s) }
+func (c colorizer) green(s string) string  { return c.wrap(ansiGreen, s) }
+func (c colorizer) yellow(s string) string { return c.wrap(ansiYellow, s) }
+func (c colorizer) cyan(s string) string   { return c.wrap(ansiCyan, s) }
+
+// isTerminal returns true if f is a character device
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// newColorizer decides about colors based on -color flag. auto enables them
+// for terminals only, unless NO_COLOR environment variable is set, see
+// https://no-color.org
+func newColorizer(mode string, f *os.File) (colorizer, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		return colorizer(isTerminal(f)), nil
+	}
+	return false, fmt.Errorf("unknown -color value %q, expected auto, always or never", mode)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vyskocilm/jf"
+)
+
+// globList is a repeatable flag with glob patterns
+type globList []string
+
+func (g *globList) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *globList) Set(value string) error {
+	if _, err := path.Match(value, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", value, err)
+	}
+	*g = append(*g, value)
+	return nil
+}
+
+// match returns true if any glob matches relative path or its base name
+func (g globList) match(rel string) bool {
+	for _, glob := range g {
+		if ok, _ := path.Match(glob, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isDir(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.IsDir()
+}
+
+// walkFiles returns slash separated paths of files relative to root, which
+// matches include globs and does not match exclude globs
+func walkFiles(root string, include, exclude globList) (map[string]struct{}, error) {
+	files := make(map[string]struct{})
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !include.match(rel) || exclude.match(rel) {
+			return nil
+		}
+		files[rel] = struct{}{}
+		return nil
+	})
+	return files, err
+}
+
+// dirReports walks both directories and diffs files with the same relative
+// path. Files present on one side only are reported too. Files, which can't
+// be read or parsed, are reported as problems, so the others are compared
+func dirReports(d *jf.Differ, dirA, dirB string, include, exclude globList) ([]*report, error) {
+	if len(include) == 0 {
+		include = globList{"*.json"}
+	}
+	filesA, err := walkFiles(dirA, include, exclude)
+	if err != nil {
+		return nil, err
+	}
+	filesB, err := walkFiles(dirB, include, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]string, 0, len(filesA)+len(filesB))
+	for rel := range filesA {
+		all = append(all, rel)
+	}
+	for rel := range filesB {
+		if _, found := filesA[rel]; !found {
+			all = append(all, rel)
+		}
+	}
+	sort.Strings(all)
+
+	reports := make([]*report, 0, len(all))
+	for _, rel := range all {
+		pathA := filepath.Join(dirA, filepath.FromSlash(rel))
+		pathB := filepath.Join(dirB, filepath.FromSlash(rel))
+		_, inA := filesA[rel]
+		_, inB := filesB[rel]
+		var r *report
+		switch {
+		case !inB:
+			r = &report{pathA: pathA, pathB: pathB, only: "A"}
+		case !inA:
+			r = &report{pathA: pathA, pathB: pathB, only: "B"}
+		default:
+			r = &report{pathA: pathA, pathB: pathB}
+			r.jsA, r.jsB, err = js(pathA, pathB)
+			if err == nil {
+				r.diff, err = d.Diff(r.jsA, r.jsB)
+			}
+			if err != nil {
+				r.problem = err.Error()
+			}
+		}
+		r.rel = rel
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// writeFileSummary prints the status of each pair of files
+func writeFileSummary(w io.Writer, reports []*report, c colorizer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	failed, problems := 0, 0
+	for _, r := range reports {
+		var status string
+		switch {
+		case r.message() != "":
+			status = c.red(r.message())
+		case len(r.diff) > 0:
+			status = c.yellow(fmt.Sprintf("%d differences", len(r.diff)))
+		default:
+			status = c.green("ok")
+		}
+		if r.failed() {
+			failed++
+		}
+		if r.problem != "" {
+			problems++
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", r.rel, status)
+	}
+	if problems > 0 {
+		fmt.Fprintf(tw, "%d files, %d differ, %d problems\n", len(reports), failed, problems)
+	} else {
+		fmt.Fprintf(tw, "%d files, %d differ\n", len(reports), failed)
+	}
+	return tw.Flush()
+}
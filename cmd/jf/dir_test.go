@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vyskocilm/jf"
+)
+
+// writeFiles creates files relative to dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+func TestDirReports(t *testing.T) {
+	root, err := ioutil.TempDir("", "jf")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	dirA, dirB := filepath.Join(root, "a"), filepath.Join(root, "b")
+
+	writeFiles(t, dirA, map[string]string{
+		"equal.json":   `{"a": 1}`,
+		"differ.json":  `{"a": 1}`,
+		"sub/bad.json": `{"a": `,
+		"only-a.json":  `{}`,
+		"skip.json":    `{"a": 1}`,
+		"readme.txt":   `text`,
+	})
+	writeFiles(t, dirB, map[string]string{
+		"equal.json":   `{"a": 1}`,
+		"differ.json":  `{"a": 2}`,
+		"sub/bad.json": `{"a": 1}`,
+		"only-b.json":  `{}`,
+		"skip.json":    `{"a": 2}`,
+	})
+
+	reports, err := dirReports(jf.NewDiffer(), dirA, dirB, nil, globList{"skip.json"})
+	require.NoError(t, err)
+	rels := make([]string, len(reports))
+	for idx, r := range reports {
+		rels[idx] = r.rel
+	}
+	assert.Equal(t, []string{"differ.json", "equal.json", "only-a.json", "only-b.json", "sub/bad.json"}, rels)
+
+	assert.Len(t, reports[0].diff, 1)
+	assert.False(t, reports[1].failed())
+	assert.Equal(t, "A", reports[2].only)
+	assert.Equal(t, "B", reports[3].only)
+	// broken file does not stop the comparison
+	assert.Contains(t, reports[4].problem, "unexpected end of JSON input")
+	assert.True(t, reports[4].failed())
+
+	var buf bytes.Buffer
+	require.NoError(t, writeFileSummary(&buf, reports, colorizer(false)))
+	assert.Contains(t, buf.String(), "differ.json  1 differences\n")
+	assert.Contains(t, buf.String(), "equal.json   ok\n")
+	assert.Contains(t, buf.String(), "5 files, 4 differ, 1 problems\n")
+
+	// include globs
+	reports, err = dirReports(jf.NewDiffer(), dirA, dirB, globList{"*.txt"}, nil)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "readme.txt", reports[0].rel)
+	assert.Equal(t, "A", reports[0].only)
+}
+
+func TestGlobList(t *testing.T) {
+	var g globList
+	assert.NoError(t, g.Set("*.json"))
+	assert.NoError(t, g.Set("sub/*.yaml"))
+	assert.Error(t, g.Set("["))
+	assert.True(t, g.match("a.json"))
+	assert.True(t, g.match("deep/dir/a.json"))
+	assert.True(t, g.match("sub/a.yaml"))
+	assert.False(t, g.match("other/a.yaml"))
+	assert.Equal(t, "*.json,sub/*.yaml", g.String())
+}
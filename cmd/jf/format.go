@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vyskocilm/jf"
+)
+
+// report is a result of diff of two files
+type report struct {
+	pathA string
+	pathB string
+	jsA   string
+	jsB   string
+	diff  jf.DiffList
+	// only is "A" or "B" if file exists on one side only
+	only string
+	// rel is relative path in directory mode
+	rel string
+	// problem is set if inputs could not be compared, like on different
+	// HTTP status
+	problem string
+}
+
+// failed returns true if files differ
+func (r *report) failed() bool {
+	return len(r.diff) > 0 || r.only != "" || r.problem != ""
+}
+
+// message describes a report, which has no differences to print
+func (r *report) message() string {
+	if r.problem != "" {
+		return r.pathA + " " + r.pathB + ": " + r.problem
+	}
+	if r.only != "" {
+		return r.onlyMessage()
+	}
+	return ""
+}
+
+func (r *report) onlyMessage() string {
+	if r.only == "A" {
+		return "only in " + r.pathA
+	}
+	return "only in " + r.pathB
+}
+
+// newReport reads and diffs two files
+func newReport(d *jf.Differ, pathA, pathB string) (*report, error) {
+	jsA, jsB, err := js(pathA, pathB)
+	if err != nil {
+		return nil, err
+	}
+	return newReportFromJSON(d, pathA, pathB, jsA, jsB)
+}
+
+// newReportFromJSON diffs two JSON documents
+func newReportFromJSON(d *jf.Differ, pathA, pathB, jsA, jsB string) (*report, error) {
+	diff, err := d.Diff(jsA, jsB)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", pathA, pathB, err)
+	}
+	return &report{
+		pathA: pathA,
+		pathB: pathB,
+		jsA:   jsA,
+		jsB:   jsB,
+		diff:  diff,
+	}, nil
+}
+
+// options affects the output
+type options struct {
+	format    string
+	context   int
+	color     colorizer
+	width     int
+	locations bool
+	// multi is true if there are more pairs of files
+	multi bool
+	// dirs is true when comparing directories
+	dirs bool
+}
+
+// formatFunc writes the reports in a given format
+type formatFunc func(w io.Writer, d *jf.Differ, reports []*report, o *options) error
+
+// reportFunc writes one report
+type reportFunc func(w io.Writer, d *jf.Differ, r *report, o *options) error
+
+var formats = map[string]formatFunc{
+	"text":         eachReport(writeText),
+	"unified":      eachReport(writeUnifiedReport),
+	"side-by-side": eachReport(writeSideBySideReport),
+	"json":         writeJSON,
+	"jsonl":        writeJSONL,
+	"html":         onlyReport(writeHTML),
+	"junit":        writeJUnit,
+	"tap":          writeTAP,
+	"markdown":     writeMarkdown,
+	"stat":         writeStat,
+}
+
+// eachReport writes reports one by one
+func eachReport(fn reportFunc) formatFunc {
+	return func(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+		for _, r := range reports {
+			if err := fn(w, d, r, o); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// onlyReport is for formats, which supports one pair of files only
+func onlyReport(fn reportFunc) formatFunc {
+	return func(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+		if len(reports) != 1 {
+			return fmt.Errorf("-format=%s supports one pair of files only", o.format)
+		}
+		return fn(w, d, reports[0], o)
+	}
+}
+
+// formatNames returns sorted list of supported formats
+func formatNames() string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func writeReports(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+	return formats[o.format](w, d, reports, o)
+}
+
+// writeText prints tab aligned selector, A and B values. Files are named
+// if there is more of them
+func writeText(w io.Writer, d *jf.Differ, r *report, o *options) error {
+	if r.message() != "" {
+		_, err := fmt.Fprintf(w, "%s\n", o.color.bold(r.message()))
+		return err
+	}
+	if len(r.diff) == 0 {
+		return nil
+	}
+	if o.multi {
+		fmt.Fprintf(w, "%s\n", o.color.bold(r.pathA+" "+r.pathB))
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, p := range r.diff {
+		if o.locations {
+			fmt.Fprintf(tw, "%s\t%s\t", location(r.pathA, p.PosA()), location(r.pathB, p.PosB()))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s", p.Selector(), p.A(), p.B())
+		if p.Message() != "" {
+			fmt.Fprintf(tw, "\t%s", p.Message())
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func writeUnifiedReport(w io.Writer, d *jf.Differ, r *report, o *options) error {
+	if r.message() != "" {
+		_, err := fmt.Fprintf(w, "%s\n", o.color.bold(r.message()))
+		return err
+	}
+	if len(r.diff) == 0 {
+		return nil
+	}
+	prettyA, prettyB, err := d.Canonical(r.jsA, r.jsB)
+	if err != nil {
+		return err
+	}
+	writeUnified(w, r.pathA, r.pathB, prettyA, prettyB, o.context, o.color)
+	return nil
+}
+
+func writeSideBySideReport(w io.Writer, d *jf.Differ, r *report, o *options) error {
+	if r.message() != "" {
+		_, err := fmt.Fprintf(w, "%s\n", o.color.bold(r.message()))
+		return err
+	}
+	if len(r.diff) == 0 {
+		return nil
+	}
+	prettyA, prettyB, err := d.Canonical(r.jsA, r.jsB)
+	if err != nil {
+		return err
+	}
+	writeSideBySide(w, r.pathA, r.pathB, prettyA, prettyB, o.width, o.color)
+	return nil
+}
+
+// jsonOnly returns a file present on one side only or a problem encoded as
+// JSON object
+func jsonOnly(r *report) ([]byte, error) {
+	kind := jf.KindRemoved.String()
+	switch {
+	case r.problem != "":
+		kind = "problem"
+	case r.only == "B":
+		kind = jf.KindAdded.String()
+	}
+	return json.Marshal(struct {
+		FileA    string `json:"fileA"`
+		FileB    string `json:"fileB"`
+		Selector string `json:"selector"`
+		Kind     string `json:"kind"`
+		Problem  string `json:"problem,omitempty"`
+	}{r.pathA, r.pathB, "", kind, r.problem})
+}
+
+// jsonDiff returns diff encoded as JSON object with file names
+func jsonDiff(r *report, p jf.SingleDiff) ([]byte, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	files, err := json.Marshal(struct {
+		FileA string `json:"fileA"`
+		FileB string `json:"fileB"`
+	}{r.pathA, r.pathB})
+	if err != nil {
+		return nil, err
+	}
+	// join {"fileA":..., "fileB":...} with {"selector": ...}
+	return append(append(files[:len(files)-1], ','), b[1:]...), nil
+}
+
+// writeJSON prints all differences as one JSON array
+func writeJSON(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+	all := make([]json.RawMessage, 0)
+	for _, r := range reports {
+		if r.message() != "" {
+			b, err := jsonOnly(r)
+			if err != nil {
+				return err
+			}
+			all = append(all, b)
+		}
+		for _, p := range r.diff {
+			b, err := jsonDiff(r, p)
+			if err != nil {
+				return err
+			}
+			all = append(all, b)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(all)
+}
+
+// writeJSONL prints one JSON object per line for each difference
+func writeJSONL(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+	for _, r := range reports {
+		if r.message() != "" {
+			b, err := jsonOnly(r)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\n", b)
+		}
+		for _, p := range r.diff {
+			b, err := jsonDiff(r, p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\n", b)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vyskocilm/jf"
+)
+
+func TestWriteReports(t *testing.T) {
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{"text", `a.json b.json
+a 1 2
+b 2 
+c   3
+only in a3.json
+a4.json b4.json: status 200 != 500
+`},
+		{"unified", `--- a.json
++++ b.json
+@@ -1,4 +1,4 @@
+ {
+-  "a": 1,
+-  "b": 2
++  "a": 2,
++  "c": 3
+ }
+only in a3.json
+a4.json b4.json: status 200 != 500
+`},
+		{"side-by-side", `a.json                                   b.json
+{                                        {
+  "a": 1,                              |   "a": 2,
+  "b": 2                               |   "c": 3
+}                                        }
+only in a3.json
+a4.json b4.json: status 200 != 500
+`},
+		{"jsonl", `{"fileA":"a.json","fileB":"b.json","selector":"a","kind":"changed","a":1,"b":2,"posA":{"offset":6,"line":1,"column":7},"posB":{"offset":6,"line":1,"column":7}}
+{"fileA":"a.json","fileB":"b.json","selector":"b","kind":"removed","a":2,"posA":{"offset":14,"line":1,"column":15},"posB":{"offset":0,"line":1,"column":1}}
+{"fileA":"a.json","fileB":"b.json","selector":"c","kind":"added","b":3,"posA":{"offset":0,"line":1,"column":1},"posB":{"offset":14,"line":1,"column":15}}
+{"fileA":"a3.json","fileB":"b3.json","selector":"","kind":"removed"}
+{"fileA":"a4.json","fileB":"b4.json","selector":"","kind":"problem","problem":"status 200 != 500"}
+`},
+		{"markdown", "**jf**: 1 changed, 1 added, 1 removed, 1 files on one side only, 1 not compared\n" + `
+### ` + "`a.json` vs `b.json`" + `
+
+#### ` + "`a`" + `
+
+| selector | A | B |
+| --- | --- | --- |
+| ` + "`a` | `1` | `2`" + ` |
+
+#### ` + "`b`" + `
+
+| selector | A | B |
+| --- | --- | --- |
+| ` + "`b` | `2`" + ` |  |
+
+#### ` + "`c`" + `
+
+| selector | A | B |
+| --- | --- | --- |
+| ` + "`c` |  | `3`" + ` |
+
+### only in ` + "`a3.json`" + `
+
+### ` + "`a4.json` vs `b4.json`" + `
+
+status 200 != 500
+`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			o := &options{format: tc.format, context: 3, width: 80, color: colorizer(false), multi: true}
+			require.NoError(t, writeReports(&buf, jf.NewDiffer(), testReports(t), o))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+// TestWriteMarkdownMessages tests schema messages and the root group
+func TestWriteMarkdownMessages(t *testing.T) {
+	s, err := jf.ParseSchema(`{"properties": {"a": {"type": "integer"}}, "minProperties": 2}`)
+	require.NoError(t, err)
+	r, err := newReportFromJSON(jf.NewDiffer().SetSchema(s), "a.json", "b.json", `{"a": 1, "b": 2}`, `{"a": "x"}`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeReports(&buf, jf.NewDiffer(), []*report{r}, &options{format: "markdown"}))
+	assert.Equal(t, "**jf**: 1 changed, 0 added, 1 removed, 2 schema violations\n"+`
+### `+"`a.json` vs `b.json`"+`
+
+#### `+"`a`"+`
+
+| selector | A | B | message |
+| --- | --- | --- | --- |
+| `+"`a` |  | `\"x\"`"+` | must be integer |
+| `+"`a` | `1` | `\"x\"`"+` |  |
+
+#### (root)
+
+| selector | A | B | message |
+| --- | --- | --- | --- |
+|  |  | `+"`{\"a\":\"x\"}`"+` | must have at least 2 properties |
+
+#### `+"`b`"+`
+
+| selector | A | B |
+| --- | --- | --- |
+| `+"`b` | `2`"+` |  |
+`, buf.String())
+}
+
+func TestWriteReportsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeReports(&buf, jf.NewDiffer(), testReports(t), &options{format: "json"}))
+
+	var lines []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &lines))
+	require.Len(t, lines, 5)
+	assert.Equal(t, "a", lines[0]["selector"])
+	assert.Equal(t, "changed", lines[0]["kind"])
+	assert.Equal(t, float64(1), lines[0]["a"])
+	assert.Equal(t, float64(2), lines[0]["b"])
+	assert.Equal(t, "removed", lines[3]["kind"])
+	assert.Equal(t, "problem", lines[4]["kind"])
+	assert.Equal(t, "status 200 != 500", lines[4]["problem"])
+}
+
+func TestWriteReportsHTML(t *testing.T) {
+	var buf bytes.Buffer
+	o := &options{format: "html"}
+	require.NoError(t, writeReports(&buf, jf.NewDiffer(), testReports(t)[:1], o))
+	html := buf.String()
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>\n"))
+	assert.Contains(t, html, "<title>jf: a.json b.json</title>")
+	assert.Contains(t, html, `<tr class="changed"><td><code>a</code></td><td>changed</td><td><code>1</code></td><td><code>2</code></td><td></td></tr>`)
+	assert.Contains(t, html, `<tr class="removed"><td><code>b</code></td>`)
+	assert.Contains(t, html, `<tr class="added"><td><code>c</code></td>`)
+	assert.Contains(t, html, `<tr><th>total</th><th>3</th></tr>`)
+
+	buf.Reset()
+	err := writeReports(&buf, jf.NewDiffer(), testReports(t), o)
+	assert.EqualError(t, err, "-format=html supports one pair of files only")
+}
+
+// TestRenderTreeAmbiguousKeys tests keys with dots and slashes mark only
+// their own nodes
+func TestRenderTreeAmbiguousKeys(t *testing.T) {
+	const jsonA = `{"a": {"b": 1}, "a.b": 2, "c/d": [1]}`
+	diff, err := jf.Diff(jsonA, `{"a": {"b": 1}, "a.b": 3, "c/d": [2]}`)
+	require.NoError(t, err)
+	tree, err := renderTree(jsonA, diff, true)
+	require.NoError(t, err)
+	html := string(tree)
+	assert.Contains(t, html, `<details class=""><summary><span class="key">a:</span>`)
+	assert.Contains(t, html, `<span class=""><span class="key">b:</span> 1</span>`)
+	assert.Contains(t, html, `<span class="changed"><span class="key">a.b:</span> 2</span>`)
+	assert.Contains(t, html, `<details class="contains" open><summary><span class="key">c/d:</span>`)
+}
+
+func TestFormatNames(t *testing.T) {
+	assert.Equal(t, "html, json, jsonl, junit, markdown, side-by-side, stat, tap, text, unified", formatNames())
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vyskocilm/jf"
+)
+
+// rulesFileName is a repository local file with rules, one per line
+const rulesFileName = ".jfrules"
+
+// loadRules reads rules file. Each line contains rule name, the same as
+// commandline flag without a dash, and an argument
+//
+//	# volatile fields
+//	ignore ^(created|updated)$
+//	float-tolerance price=0.01
+func loadRules(d *jf.Differ, r io.Reader, name string) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("%s:%d: expected rule and argument, got %q", name, lineNo, line)
+		}
+		if err := addRule(d, fields[0], strings.TrimSpace(fields[1])); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func loadRulesFile(d *jf.Differ, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return loadRules(d, f, path)
+}
+
+// findRulesFile looks for .jfrules in current directory and its parents up
+// to the root of git repository
+func findRulesFile() (string, bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	for {
+		path := filepath.Join(dir, rulesFileName)
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path, true
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// readGitFile reads file passed by git, /dev/null means added or deleted
+// file, which is compared as an empty object
+func readGitFile(path string) (string, error) {
+	if path == os.DevNull {
+		return "{}", nil
+	}
+	return readPath(path)
+}
+
+// gitDifftool implements GIT_EXTERNAL_DIFF calling convention
+//
+//	path old-file old-hex old-mode new-file new-hex new-mode [new-path header]
+//
+// Renamed or copied files have new path and a header like "similarity
+// index 90%" as well. It must exit with zero, otherwise git stops the diff.
+func gitDifftool(w io.Writer, d *jf.Differ, args []string, o *options) error {
+	if len(args) == 1 {
+		_, err := fmt.Fprintf(w, "* Unmerged path %s\n", args[0])
+		return err
+	}
+	if len(args) != 7 && len(args) != 9 {
+		return fmt.Errorf("git-difftool: expected 7 or 9 arguments from git, got %d", len(args))
+	}
+	path, newPath, header := args[0], args[0], ""
+	if len(args) == 9 {
+		newPath, header = args[7], args[8]
+	}
+	jsA, err := readGitFile(args[1])
+	if err != nil {
+		return err
+	}
+	jsB, err := readGitFile(args[4])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s\n", o.color.bold(fmt.Sprintf("diff --jf a/%s b/%s", path, newPath)))
+	if header != "" {
+		fmt.Fprintf(w, "%s\n", o.color.bold(strings.TrimSuffix(header, "\n")))
+	}
+	r, err := newReportFromJSON(d, "a/"+path, "b/"+newPath, jsA, jsB)
+	if err != nil {
+		// invalid JSON must not stop git diff
+		_, err = fmt.Fprintf(w, "jf: %s\n", err)
+		return err
+	}
+	o.multi = false
+	return writeReports(w, d, []*report{r}, o)
+}
+
+// gitTextconv prints canonical form of a file for diff.<driver>.textconv.
+// Invalid JSON is printed unchanged, so git diff does not fail.
+func gitTextconv(w io.Writer, d *jf.Differ, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("git-textconv: expected one file, got %d", len(args))
+	}
+	js, err := readGitFile(args[0])
+	if err != nil {
+		return err
+	}
+	pretty, _, err := d.Canonical(js, js)
+	if err != nil {
+		pretty = js
+	}
+	_, err = fmt.Fprint(w, pretty)
+	return err
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vyskocilm/jf"
+)
+
+func TestLoadRules(t *testing.T) {
+	d := jf.NewDiffer()
+	err := loadRules(d, strings.NewReader(`
+# volatile fields
+ignore ^(created|updated)$
+ignore-order   tags
+float-tolerance price=0.01
+`), ".jfrules")
+	require.NoError(t, err)
+	diff, err := d.Diff(
+		`{"created": 1, "tags": ["a", "b"], "price": 1.0, "id": 1}`,
+		`{"created": 2, "tags": ["b", "a"], "price": 1.005, "id": 2}`)
+	require.NoError(t, err)
+	require.Len(t, diff, 1)
+	assert.Equal(t, "id", diff[0].Selector())
+
+	testCases := []struct {
+		rules string
+		err   string
+	}{
+		{"ignore", `.jfrules:1: expected rule and argument, got "ignore"`},
+		{"\nunknown x", `.jfrules:2: unknown rule "unknown"`},
+		{"ignore (", ".jfrules:1: error parsing regexp: missing closing ): `(`"},
+		{"float-tolerance price", `.jfrules:1: expected path=eps, got "price"`},
+		{"float-tolerance price=-1", `.jfrules:1: tolerance must be a positive number, got "-1"`},
+		{"array-key items=", `.jfrules:1: expected path=key, got "items="`},
+	}
+	for _, tc := range testCases {
+		err := loadRules(jf.NewDiffer(), strings.NewReader(tc.rules), ".jfrules")
+		assert.EqualError(t, err, tc.err, tc.rules)
+	}
+}
+
+func TestLoadRulesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, rulesFileName)
+	require.NoError(t, ioutil.WriteFile(path, []byte("ignore ^id$\n"), 0644))
+	d := jf.NewDiffer()
+	require.NoError(t, loadRulesFile(d, path))
+	equal, err := d.Equal(`{"id": 1}`, `{"id": 2}`)
+	require.NoError(t, err)
+	assert.True(t, equal)
+
+	assert.Error(t, loadRulesFile(jf.NewDiffer(), filepath.Join(dir, "missing")))
+}
+
+func TestGitDifftool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"old.json": `{"a": 1}`,
+		"new.json": `{"a": 2}`,
+		"bad.json": `{`,
+	})
+	old, new, bad := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json"), filepath.Join(dir, "bad.json")
+	o := &options{format: "text"}
+
+	var buf bytes.Buffer
+	require.NoError(t, gitDifftool(&buf, jf.NewDiffer(), []string{"x.json", old, "1111", "100644", new, "2222", "100644"}, o))
+	assert.Equal(t, "diff --jf a/x.json b/x.json\na 1 2\n", buf.String())
+
+	// added file
+	buf.Reset()
+	require.NoError(t, gitDifftool(&buf, jf.NewDiffer(), []string{"x.json", os.DevNull, ".", ".", new, "2222", "100644"}, o))
+	assert.Equal(t, "diff --jf a/x.json b/x.json\na  2\n", buf.String())
+
+	// invalid JSON does not stop git
+	buf.Reset()
+	require.NoError(t, gitDifftool(&buf, jf.NewDiffer(), []string{"x.json", bad, "1111", "100644", new, "2222", "100644"}, o))
+	assert.Contains(t, buf.String(), "jf: a/x.json b/x.json: jsonA:")
+
+	// renamed file
+	buf.Reset()
+	require.NoError(t, gitDifftool(&buf, jf.NewDiffer(), []string{"x.json", old, "1111", "100644", new, "2222", "100644", "y.json", "similarity index 90%\nrename from x.json\nrename to y.json\n"}, o))
+	assert.Equal(t, "diff --jf a/x.json b/y.json\nsimilarity index 90%\nrename from x.json\nrename to y.json\na 1 2\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, gitDifftool(&buf, jf.NewDiffer(), []string{"x.json"}, o))
+	assert.Equal(t, "* Unmerged path x.json\n", buf.String())
+
+	assert.Error(t, gitDifftool(&buf, jf.NewDiffer(), []string{"x.json", old}, o))
+}
+
+func TestGitTextconv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{"x.json": `{"b": [1, 2], "a": null}`, "bad.json": `{"a": `})
+
+	var buf bytes.Buffer
+	require.NoError(t, gitTextconv(&buf, jf.NewDiffer(), []string{filepath.Join(dir, "x.json")}))
+	assert.Equal(t, "{\n  \"a\": null,\n  \"b\": [\n    1,\n    2\n  ]\n}\n", buf.String())
+
+	// invalid JSON is printed as is
+	buf.Reset()
+	require.NoError(t, gitTextconv(&buf, jf.NewDiffer(), []string{filepath.Join(dir, "bad.json")}))
+	assert.Equal(t, `{"a": `, buf.String())
+
+	assert.Error(t, gitTextconv(&buf, jf.NewDiffer(), nil))
+}
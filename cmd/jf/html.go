@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/vyskocilm/jf"
+)
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>jf: {{.PathA}} {{.PathB}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; vertical-align: top; }
+code { font-family: monospace; white-space: pre-wrap; }
+.tree { font-family: monospace; }
+.columns { display: flex; gap: 2em; }
+.columns > div { flex: 1; overflow-x: auto; }
+.tree ul { list-style: none; margin: 0; padding-left: 1.5em; }
+.tree summary { cursor: pointer; }
+.key { color: #555; }
+.changed { background: #fff3b0; }
+.removed { background: #ffd6d6; }
+.added { background: #d6ffd6; }
+.duplicate-key { background: #ffd6a5; }
+.schema { background: #e0d6ff; }
+.contains > summary { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>jf: {{.PathA}} vs {{.PathB}}</h1>
+<h2>Summary</h2>
+<table>
+<tr><th>kind</th><th>count</th></tr>
+{{range .Summary}}<tr><td class="{{.Kind}}">{{.Kind}}</td><td>{{.Count}}</td></tr>
+{{end}}<tr><th>total</th><th>{{len .Diff}}</th></tr>
+</table>
+<h2>Rules</h2>
+{{if .Rules}}<ul>
+{{range .Rules}}<li><code>{{.}}</code></li>
+{{end}}</ul>{{else}}<p>No rules, exact comparison.</p>{{end}}
+<h2>Differences</h2>
+{{if .Diff}}<table>
+<tr><th>selector</th><th>kind</th><th>{{.PathA}}</th><th>{{.PathB}}</th><th>message</th></tr>
+{{range .Diff}}<tr class="{{.Kind}}"><td><code>{{.Selector}}</code></td><td>{{.Kind}}</td><td><code>{{.A}}</code></td><td><code>{{.B}}</code></td><td>{{.Message}}</td></tr>
+{{end}}</table>{{else}}<p>No differences.</p>{{end}}
+<h2>Documents</h2>
+<div class="columns">
+<div><h3>{{.PathA}}</h3><div class="tree">{{.TreeA}}</div></div>
+<div><h3>{{.PathB}}</h3><div class="tree">{{.TreeB}}</div></div>
+</div>
+</body>
+</html>
+`))
+
+type htmlSummary struct {
+	Kind  string
+	Count int
+}
+
+type htmlDiff struct {
+	Selector string
+	Kind     string
+	A        string
+	B        string
+	Message  string
+}
+
+type htmlData struct {
+	PathA   string
+	PathB   string
+	Summary []htmlSummary
+	Rules   []string
+	Diff    []htmlDiff
+	TreeA   template.HTML
+	TreeB   template.HTML
+}
+
+// pointerEscaper escapes a key in JSON Pointer
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// htmlTree renders JSON document as nested collapsible lists. Nodes are
+// identified by JSON Pointers, selectors are ambiguous for keys with dots.
+type htmlTree struct {
+	b bytes.Buffer
+	// kinds of differences on this side
+	kinds map[string]string
+	// pointers which have a difference below
+	contains map[string]struct{}
+}
+
+func newHTMLTree(diff jf.DiffList, isA bool) *htmlTree {
+	t := &htmlTree{
+		kinds:    make(map[string]string),
+		contains: make(map[string]struct{}),
+	}
+	for _, p := range diff {
+		pointer := p.PointerA()
+		if !isA {
+			pointer = p.PointerB()
+		}
+		if (isA && p.Kind() == jf.KindAdded) || (!isA && p.Kind() == jf.KindRemoved) {
+			// missing on this side, mark the parent
+			t.markParents(pointer)
+			continue
+		}
+		if p.Kind() == jf.KindDuplicateKey && ((isA && p.A() == "") || (!isA && p.B() == "")) {
+			continue
+		}
+		if p.Kind() == jf.KindSchema && isA != p.PosA().IsValid() {
+			continue
+		}
+		t.kinds[pointer] = p.Kind().String()
+		t.markParents(pointer)
+	}
+	return t
+}
+
+func (t *htmlTree) markParents(pointer string) {
+	for pointer != "" {
+		pointer = pointer[:strings.LastIndex(pointer, "/")]
+		t.contains[pointer] = struct{}{}
+	}
+}
+
+func (t *htmlTree) class(pointer string) string {
+	classes := make([]string, 0, 2)
+	if kind, found := t.kinds[pointer]; found {
+		classes = append(classes, kind)
+	}
+	if _, found := t.contains[pointer]; found {
+		classes = append(classes, "contains")
+	}
+	return strings.Join(classes, " ")
+}
+
+func (t *htmlTree) label(key string) string {
+	if key == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<span class="key">%s:</span> `, html.EscapeString(key))
+}
+
+func (t *htmlTree) node(pointer, key string, v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		t.open(pointer, key, fmt.Sprintf("{…} %d keys", len(keys)))
+		for _, k := range keys {
+			t.b.WriteString("<li>")
+			t.node(pointer+"/"+pointerEscaper.Replace(k), k, value[k])
+			t.b.WriteString("</li>\n")
+		}
+		t.b.WriteString("</ul></details>")
+	case []interface{}:
+		t.open(pointer, key, fmt.Sprintf("[…] %d items", len(value)))
+		for idx, item := range value {
+			t.b.WriteString("<li>")
+			t.node(fmt.Sprintf("%s/%d", pointer, idx), fmt.Sprintf("%d", idx), item)
+			t.b.WriteString("</li>\n")
+		}
+		t.b.WriteString("</ul></details>")
+	default:
+		b, _ := json.Marshal(value)
+		fmt.Fprintf(&t.b, `<span class="%s">%s%s</span>`, t.class(pointer), t.label(key), html.EscapeString(string(b)))
+	}
+}
+
+// open starts collapsible node, nodes with changes are expanded
+func (t *htmlTree) open(pointer, key, summary string) {
+	open := ""
+	if _, found := t.contains[pointer]; found || pointer == "" {
+		open = " open"
+	}
+	fmt.Fprintf(&t.b, `<details class="%s"%s><summary>%s%s</summary><ul>`,
+		t.class(pointer), open, t.label(key), html.EscapeString(summary))
+}
+
+func renderTree(js string, diff jf.DiffList, isA bool) (template.HTML, error) {
+	dec := json.NewDecoder(strings.NewReader(js))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+	t := newHTMLTree(diff, isA)
+	t.node("", "", v)
+	return template.HTML(t.b.String()), nil
+}
+
+// writeHTML prints self contained HTML report
+func writeHTML(w io.Writer, d *jf.Differ, r *report, o *options) error {
+	if r.message() != "" {
+		return fmt.Errorf("%s", r.message())
+	}
+	data := htmlData{
+		PathA: r.pathA,
+		PathB: r.pathB,
+		Rules: d.Rules(),
+		Diff:  make([]htmlDiff, 0, len(r.diff)),
+	}
+
+	counts := make(map[jf.DiffKind]int)
+	for _, p := range r.diff {
+		counts[p.Kind()]++
+		data.Diff = append(data.Diff, htmlDiff{
+			Selector: p.Selector(),
+			Kind:     p.Kind().String(),
+			A:        p.A(),
+			B:        p.B(),
+			Message:  p.Message(),
+		})
+	}
+	for _, kind := range []jf.DiffKind{jf.KindChanged, jf.KindAdded, jf.KindRemoved, jf.KindDuplicateKey, jf.KindSchema} {
+		if counts[kind] > 0 {
+			data.Summary = append(data.Summary, htmlSummary{Kind: kind.String(), Count: counts[kind]})
+		}
+	}
+
+	var err error
+	data.TreeA, err = renderTree(r.jsA, r.diff, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.pathA, err)
+	}
+	data.TreeB, err = renderTree(r.jsB, r.diff, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.pathB, err)
+	}
+	return htmlReport.Execute(w, data)
+}
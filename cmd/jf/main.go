@@ -4,10 +4,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
-	"regexp"
-	"text/tabwriter"
+	"strings"
 
 	"github.com/vyskocilm/jf"
 )
@@ -18,69 +18,316 @@ const (
 	exitTroubles = 2
 )
 
+// readJSON reads the whole input from any io.Reader
+func readJSON(r io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// open opens the file, "-" means standard input
+func open(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// readPath reads JSON from a file or standard input
+func readPath(path string) (string, error) {
+	f, err := open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return readJSON(f)
+}
+
 func js(pathA, pathB string) (string, string, error) {
-	bytesA, err := ioutil.ReadFile(pathA)
+	jsA, err := readPath(pathA)
 	if err != nil {
 		return "", "", err
 	}
-	bytesB, err := ioutil.ReadFile(pathB)
+	jsB, err := readPath(pathB)
 	if err != nil {
 		return "", "", err
 	}
 
-	jsA := string(bytesA)
-	jsB := string(bytesB)
-
 	return jsA, jsB, nil
 }
 
-func makeRules(d *jf.Differ, ignoreB *string) error {
+func makeRules(d *jf.Differ, duplicateKeys, compare *string) error {
 
-	if *ignoreB != "" {
-		rg, err := regexp.Compile(*ignoreB)
-		if err != nil {
-			return err
-		}
-		d.AddIgnore(jf.RuleB, rg)
+	switch *duplicateKeys {
+	case "ignore":
+		d.SetDuplicateKeys(jf.DuplicateKeysIgnore)
+	case "error":
+		d.SetDuplicateKeys(jf.DuplicateKeysError)
+	case "report":
+		d.SetDuplicateKeys(jf.DuplicateKeysReport)
+	default:
+		return fmt.Errorf("unknown -duplicate-keys value %q, expected ignore, error or report", *duplicateKeys)
+	}
+
+	switch *compare {
+	case "exact":
+		d.SetCompareMode(jf.CompareExact)
+	case "subset":
+		d.SetCompareMode(jf.CompareSubset)
+	case "superset":
+		d.SetCompareMode(jf.CompareSuperset)
+	default:
+		return fmt.Errorf("unknown -compare value %q, expected exact, subset or superset", *compare)
 	}
 
 	return nil
 }
 
+// location returns path:line:column of a value, which editors understand
+func location(path string, pos jf.Position) string {
+	if !pos.IsValid() {
+		return path
+	}
+	return fmt.Sprintf("%s:%d:%d", path, pos.Line, pos.Column)
+}
+
 func main() {
 
-	// FIXME: specify meaningful cmd arguments
+	d := jf.NewDiffer()
+	for _, r := range rules {
+		flag.Var(&ruleFlag{d: d, fn: r.fn}, r.name, r.usage+", can be repeated")
+	}
+
 	var (
-		ignoreB = flag.String("x-ignore-b", "", "ignore keys from b.json")
+		duplicateKeys = flag.String("duplicate-keys", "ignore", "handling of duplicate keys: ignore, error or report")
+		compare       = flag.String("compare", "exact", "exact, subset if a.json must be contained in b.json or superset for the opposite")
+		locations     = flag.Bool("locations", false, "print a.json:line:column b.json:line:column of each difference")
+		format        = flag.String("format", "text", "output format: "+formatNames())
+		context       = flag.Int("context", 3, "number of context lines for -format=unified")
+		color         = flag.String("color", "auto", "colorize the output: auto, always or never, auto honors NO_COLOR")
+		width         = flag.Int("width", 0, "output width for -format=side-by-side, detected from terminal by default")
+		schemaFile    = flag.String("schema", "", "validate both inputs against JSON Schema file, violations are reported as differences")
+		rulesFile     = flag.String("rules", "", "read rules from file, git modes use "+rulesFileName+" from repository by default")
+		quiet         = flag.Bool("quiet", false, "print nothing, only set the exit status")
+		maxDiffs      = flag.Int("max-diffs", 0, "stop after N differences, 0 means no limit")
+		failOnFlag    = flag.String("fail-on", strings.Join(allKinds, ","), "comma separated kinds of differences causing exit status 1")
+		stat          = flag.Bool("stat", false, "print only number of differences per file, the same as -format=stat")
+		baseA         = flag.String("a", "", "base URL of server A in http mode")
+		baseB         = flag.String("b", "", "base URL of server B in http mode")
+		requests      = flag.String("requests", "", "JSON lines file with requests to replay in http mode")
+		include       globList
+		exclude       globList
 	)
+	flag.Var(&include, "include", "compare only files matching glob in directory mode, can be repeated (default *.json)")
+	flag.Var(&exclude, "exclude", "skip files matching glob in directory mode, can be repeated")
 	flag.Parse()
+	httpMode := flag.Arg(0) == "http"
+	if httpMode {
+		// jf http -a URL -b URL ...
+		_ = flag.CommandLine.Parse(flag.Args()[1:])
+	}
 
-	d := jf.NewDiffer()
-	err := makeRules(d, ignoreB)
+	err := makeRules(d, duplicateKeys, compare)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error parsing commandline flags: %s", err)
+		fmt.Fprintf(os.Stderr, "error parsing commandline flags: %s\n", err)
 		os.Exit(exitTroubles)
 	}
-
-	if len(flag.Args()) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: jf a.json b.json\n")
+	gitMode := flag.Arg(0) == "git-difftool" || flag.Arg(0) == "git-textconv"
+	if *rulesFile == "" && gitMode {
+		*rulesFile, _ = findRulesFile()
+	}
+	if *rulesFile != "" {
+		err = loadRulesFile(d, *rulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(exitTroubles)
+		}
+	}
+	if *schemaFile != "" {
+		schema, err := jf.LoadSchema(*schemaFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(exitTroubles)
+		}
+		d.SetSchema(schema)
+	}
+	fails, err := parseFailOn(*failOnFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing commandline flags: %s\n", err)
 		os.Exit(exitTroubles)
 	}
-	jsA, jsB, err := js(flag.Arg(0), flag.Arg(1))
+	if *stat {
+		*format = "stat"
+	}
+	d.SetMaxDiffs(*maxDiffs)
+	c, err := newColorizer(*color, os.Stdout)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, err.Error())
+		fmt.Fprintf(os.Stderr, "error parsing commandline flags: %s\n", err)
 		os.Exit(exitTroubles)
 	}
+	if _, found := formats[*format]; !found {
+		fmt.Fprintf(os.Stderr, "error parsing commandline flags: unknown -format value %q\n", *format)
+		os.Exit(exitTroubles)
+	}
+	opts := &options{
+		format:    *format,
+		context:   *context,
+		color:     c,
+		width:     terminalWidth(*width, os.Stdout),
+		locations: *locations,
+	}
 
-	diff, err := d.Diff(jsA, jsB)
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
-	for _, p := range diff {
-		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Selector(), p.A(), p.B())
+	switch flag.Arg(0) {
+	case "git-difftool":
+		err = gitDifftool(os.Stdout, d, flag.Args()[1:], opts)
+	case "git-textconv":
+		err = gitTextconv(os.Stdout, d, flag.Args()[1:])
 	}
+	if gitMode {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(exitTroubles)
+		}
+		os.Exit(exitNoDiff)
+	}
+	stop := earlyStop(*maxDiffs, fails)
+	d.SetMaxDiffs(stop)
 
-	if len(diff) == 0 {
+	if flag.Arg(0) == "schema-diff" {
+		w := io.Writer(os.Stdout)
+		if *quiet {
+			w = nil
+		}
+		breaking, err := schemaDiff(w, d, flag.Args()[1:], opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(exitTroubles)
+		}
+		if breaking {
+			os.Exit(exitDiff)
+		}
 		os.Exit(exitNoDiff)
 	}
-	w.Flush()
-	os.Exit(exitDiff)
+
+	if httpMode {
+		if flag.NArg() != 0 {
+			fmt.Fprintf(os.Stderr, "http mode does not accept arguments: %s\n", strings.Join(flag.Args(), " "))
+			os.Exit(exitTroubles)
+		}
+		reports, err := httpReports(d, *baseA, *baseB, *requests)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(exitTroubles)
+		}
+		failed := fails.failsAny(reports)
+		reports, limited := limitDiffs(reports, *maxDiffs)
+		if !*quiet {
+			opts.multi = true
+			err = writeReports(os.Stdout, d, reports, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				os.Exit(exitTroubles)
+			}
+			if limited {
+				fmt.Fprintf(os.Stderr, "jf: stopped after %d differences\n", *maxDiffs)
+			}
+		}
+		exit(failed)
+	}
+
+	if len(flag.Args()) < 2 || len(flag.Args())%2 != 0 {
+		fmt.Fprintf(os.Stderr, "Usage: jf a.json b.json [a2.json b2.json ...], - reads standard input\n")
+		fmt.Fprintf(os.Stderr, "       jf dirA dirB\n")
+		fmt.Fprintf(os.Stderr, "       jf git-difftool path old-file old-hex old-mode new-file new-hex new-mode [new-path header]\n")
+		fmt.Fprintf(os.Stderr, "       jf git-textconv file\n")
+		fmt.Fprintf(os.Stderr, "       jf http -a URL -b URL -requests reqs.jsonl\n")
+		fmt.Fprintf(os.Stderr, "       jf schema-diff old.schema.json new.schema.json\n")
+		os.Exit(exitTroubles)
+	}
+
+	stdin := 0
+	for _, arg := range flag.Args() {
+		if arg == "-" {
+			stdin++
+		}
+	}
+	if stdin > 1 {
+		fmt.Fprintf(os.Stderr, "standard input can be used only once\n")
+		os.Exit(exitTroubles)
+	}
+
+	var reports []*report
+	if len(flag.Args()) == 2 && isDir(flag.Arg(0)) && isDir(flag.Arg(1)) {
+		opts.dirs = true
+		reports, err = dirReports(d, flag.Arg(0), flag.Arg(1), include, exclude)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(exitTroubles)
+		}
+	} else {
+		reports, err = pairReports(d, flag.Args(), stop)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(exitTroubles)
+		}
+	}
+
+	// the exit status must not depend on the display limit
+	failed := fails.failsAny(reports)
+	reports, limited := limitDiffs(reports, *maxDiffs)
+	if *quiet {
+		exit(failed)
+	}
+
+	opts.multi = len(reports) > 1 || opts.dirs
+	err = writeReports(os.Stdout, d, reports, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(exitTroubles)
+	}
+	if limited {
+		fmt.Fprintf(os.Stderr, "jf: stopped after %d differences\n", *maxDiffs)
+	}
+	if opts.dirs {
+		switch *format {
+		case "text", "unified", "side-by-side":
+			fmt.Println()
+			err = writeFileSummary(os.Stdout, reports, c)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				os.Exit(exitTroubles)
+			}
+		}
+	}
+
+	exit(failed)
+}
+
+// pairReports diffs pairs of files. It does not read next pairs once stop
+// differences were found, zero means no limit
+func pairReports(d *jf.Differ, args []string, stop int) ([]*report, error) {
+	reports := make([]*report, 0, len(args)/2)
+	total := 0
+	for idx := 0; idx+1 < len(args); idx += 2 {
+		r, err := newReport(d, args[idx], args[idx+1])
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+		total += len(r.diff)
+		if stop > 0 && total >= stop {
+			break
+		}
+	}
+	return reports, nil
+}
+
+// exit exits with exitDiff if failed
+func exit(failed bool) {
+	if failed {
+		os.Exit(exitDiff)
+	}
+	os.Exit(exitNoDiff)
 }
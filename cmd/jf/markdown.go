@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vyskocilm/jf"
+)
+
+// markdownMaxValue is the maximum length of value in a table
+const markdownMaxValue = 60
+
+// summary returns "12 changed, 3 added, 1 removed" line
+func summary(reports []*report) string {
+	all := make(jf.DiffList, 0)
+	for _, r := range reports {
+		all = append(all, r.diff...)
+	}
+	parts := []string{kindCounts(all)}
+	only, problems := 0, 0
+	for _, r := range reports {
+		if r.only != "" {
+			only++
+		}
+		if r.problem != "" {
+			problems++
+		}
+	}
+	if only > 0 {
+		parts = append(parts, fmt.Sprintf("%d files on one side only", only))
+	}
+	if problems > 0 {
+		parts = append(parts, fmt.Sprintf("%d not compared", problems))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// topLevelKey returns the first part of selector, so "data" for "data[0].id"
+func topLevelKey(selector string) string {
+	if idx := strings.IndexAny(selector, ".["); idx > 0 {
+		return selector[:idx]
+	}
+	return selector
+}
+
+// markdownCode formats value as inline code usable inside a table
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) > markdownMaxValue {
+		s = string(r[:markdownMaxValue-1]) + "…"
+	}
+	s = strings.ReplaceAll(s, "|", `\|`)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+// writeMarkdown prints summary and tables of differences grouped by top
+// level key, suitable for pull request comments. Differences of the whole
+// document are in the (root) group.
+func writeMarkdown(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+	fmt.Fprintf(w, "**jf**: %s\n", summary(reports))
+	for _, r := range reports {
+		if r.only != "" {
+			fmt.Fprintf(w, "\n### %s\n", strings.Replace(r.onlyMessage(), "only in ", "only in `", 1)+"`")
+			continue
+		}
+		if r.problem != "" {
+			fmt.Fprintf(w, "\n### `%s` vs `%s`\n\n%s\n", r.pathA, r.pathB, r.problem)
+			continue
+		}
+		if len(r.diff) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n### `%s` vs `%s`\n", r.pathA, r.pathB)
+
+		groups := make(map[string][]jf.SingleDiff)
+		keys := make([]string, 0)
+		for _, p := range r.diff {
+			key := topLevelKey(p.Selector())
+			if _, found := groups[key]; !found {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], p)
+		}
+
+		for _, key := range keys {
+			if key == "" {
+				fmt.Fprintf(w, "\n#### (root)\n\n")
+			} else {
+				fmt.Fprintf(w, "\n#### `%s`\n\n", key)
+			}
+			// the message column only for groups with schema violations
+			withMessage := false
+			for _, p := range groups[key] {
+				withMessage = withMessage || p.Message() != ""
+			}
+			if withMessage {
+				fmt.Fprintf(w, "| selector | A | B | message |\n")
+				fmt.Fprintf(w, "| --- | --- | --- | --- |\n")
+			} else {
+				fmt.Fprintf(w, "| selector | A | B |\n")
+				fmt.Fprintf(w, "| --- | --- | --- |\n")
+			}
+			for _, p := range groups[key] {
+				fmt.Fprintf(w, "| %s | %s | %s |", markdownCode(p.Selector()), markdownCode(p.A()), markdownCode(p.B()))
+				if withMessage {
+					fmt.Fprintf(w, " %s |", strings.ReplaceAll(p.Message(), "|", `\|`))
+				}
+				fmt.Fprintln(w)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vyskocilm/jf"
+)
+
+// replayTimeout is a timeout of each HTTP request in http mode
+const replayTimeout = 30 * time.Second
+
+// replayRequest is one line of requests file
+//
+//	{"method": "POST", "path": "/users", "header": {"X-Token": "t"}, "body": {"name": "foo"}}
+type replayRequest struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Header map[string]string `json:"header"`
+	Body   json.RawMessage   `json:"body"`
+}
+
+// readRequests reads requests in JSON lines format, empty lines are skipped
+func readRequests(path string) ([]replayRequest, error) {
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ret := make([]replayRequest, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req replayRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if req.Method == "" {
+			req.Method = http.MethodGet
+		}
+		if !strings.HasPrefix(req.Path, "/") {
+			req.Path = "/" + req.Path
+		}
+		ret = append(ret, req)
+	}
+	return ret, scanner.Err()
+}
+
+// replayResponse is a status and body returned by a server
+type replayResponse struct {
+	status int
+	body   string
+}
+
+// replay sends request to server at base URL
+func replay(client *http.Client, base string, req replayRequest) (*replayResponse, error) {
+	var body io.Reader
+	if len(req.Body) > 0 {
+		body = bytes.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequest(req.Method, strings.TrimSuffix(base, "/")+req.Path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range req.Header {
+		httpReq.Header.Set(key, value)
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &replayResponse{status: resp.StatusCode, body: string(b)}, nil
+}
+
+// replayReports replays each request against both servers and diffs the
+// responses. Failed requests, different status codes and bodies, which are
+// not JSON objects are reported as problems
+func replayReports(d *jf.Differ, client *http.Client, baseA, baseB string, reqs []replayRequest) []*report {
+	reports := make([]*report, 0, len(reqs))
+	for _, req := range reqs {
+		pathA := req.Method + " " + strings.TrimSuffix(baseA, "/") + req.Path
+		pathB := req.Method + " " + strings.TrimSuffix(baseB, "/") + req.Path
+		r := &report{pathA: pathA, pathB: pathB}
+		reports = append(reports, r)
+
+		respA, err := replay(client, baseA, req)
+		if err != nil {
+			r.problem = err.Error()
+			continue
+		}
+		respB, err := replay(client, baseB, req)
+		if err != nil {
+			r.problem = err.Error()
+			continue
+		}
+		if respA.status != respB.status {
+			r.problem = fmt.Sprintf("status %d != %d", respA.status, respB.status)
+			continue
+		}
+		if strings.TrimSpace(respA.body) == "" && strings.TrimSpace(respB.body) == "" {
+			continue
+		}
+		r.jsA, r.jsB = respA.body, respB.body
+		r.diff, err = d.Diff(r.jsA, r.jsB)
+		if err != nil {
+			r.problem = err.Error()
+		}
+	}
+	return reports
+}
+
+// httpReports implements jf http mode
+func httpReports(d *jf.Differ, baseA, baseB, requests string) ([]*report, error) {
+	if baseA == "" || baseB == "" || requests == "" {
+		return nil, fmt.Errorf("http mode needs -a, -b and -requests")
+	}
+	reqs, err := readRequests(requests)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: replayTimeout}
+	return replayReports(d, client, baseA, baseB, reqs), nil
+}
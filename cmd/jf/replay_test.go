@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vyskocilm/jf"
+)
+
+func TestReadRequests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"requests.jsonl": `{"path": "users"}
+
+{"method": "POST", "path": "/users", "header": {"X-Token": "t"}, "body": {"name": "foo"}}
+`,
+		"malformed.jsonl": `{"path": "/users"}
+{"path": "/users"
+`,
+	})
+
+	reqs, err := readRequests(filepath.Join(dir, "requests.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, []replayRequest{
+		{Method: "GET", Path: "/users"},
+		{Method: "POST", Path: "/users", Header: map[string]string{"X-Token": "t"}, Body: []byte(`{"name": "foo"}`)},
+	}, reqs)
+
+	path := filepath.Join(dir, "malformed.jsonl")
+	_, err = readRequests(path)
+	assert.EqualError(t, err, path+":2: unexpected end of JSON input")
+
+	_, err = readRequests(filepath.Join(dir, "missing.jsonl"))
+	assert.Error(t, err)
+
+	_, err = httpReports(jf.NewDiffer(), "http://a", "", path)
+	assert.EqualError(t, err, "http mode needs -a, -b and -requests")
+}
+
+// replayServer returns server responding with status and body for a path
+func replayServer(responses map[string]string, statuses map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/echo" {
+			b, _ := ioutil.ReadAll(r.Body)
+			fmt.Fprintf(w, `{"method": %q, "token": %q, "type": %q, "body": %s}`,
+				r.Method, r.Header.Get("X-Token"), r.Header.Get("Content-Type"), b)
+			return
+		}
+		if status, ok := statuses[r.URL.Path]; ok {
+			w.WriteHeader(status)
+		}
+		fmt.Fprint(w, responses[r.URL.Path])
+	}))
+}
+
+func TestReplayReports(t *testing.T) {
+	serverA := replayServer(map[string]string{
+		"/same":   `{"id": 1}`,
+		"/diff":   `{"id": 1, "name": "foo"}`,
+		"/status": `{"id": 1}`,
+		"/text":   `hello`,
+	}, map[string]int{"/empty": http.StatusNoContent})
+	defer serverA.Close()
+	serverB := replayServer(map[string]string{
+		"/same":   `{"id": 1}`,
+		"/diff":   `{"id": 2, "name": "foo"}`,
+		"/status": `{"error": "boom"}`,
+		"/text":   `hello`,
+	}, map[string]int{"/status": http.StatusInternalServerError, "/empty": http.StatusNoContent})
+	defer serverB.Close()
+
+	reqs := []replayRequest{
+		{Method: "GET", Path: "/same"},
+		{Method: "GET", Path: "/diff"},
+		{Method: "GET", Path: "/status"},
+		{Method: "GET", Path: "/text"},
+		{Method: "DELETE", Path: "/empty"},
+		{Method: "POST", Path: "/echo", Header: map[string]string{"X-Token": "t"}, Body: []byte(`{"name": "foo"}`)},
+	}
+	reports := replayReports(jf.NewDiffer(), serverA.Client(), serverA.URL, serverB.URL+"/", reqs)
+	require.Len(t, reports, 6)
+
+	same := reports[0]
+	assert.Equal(t, "GET "+serverA.URL+"/same", same.pathA)
+	assert.Equal(t, "GET "+serverB.URL+"/same", same.pathB)
+	assert.Equal(t, "", same.problem)
+	assert.Len(t, same.diff, 0)
+
+	diff := reports[1]
+	assert.Equal(t, "", diff.problem)
+	require.Len(t, diff.diff, 1)
+	assert.Equal(t, "id", diff.diff[0].Selector())
+	assert.Equal(t, "1", diff.diff[0].A())
+	assert.Equal(t, "2", diff.diff[0].B())
+
+	assert.Equal(t, "status 200 != 500", reports[2].problem)
+	assert.Len(t, reports[2].diff, 0)
+
+	assert.Contains(t, reports[3].problem, "jsonA: ")
+	assert.Len(t, reports[3].diff, 0)
+
+	assert.Equal(t, "", reports[4].problem)
+	assert.Len(t, reports[4].diff, 0)
+
+	echo := reports[5]
+	assert.Equal(t, "", echo.problem)
+	assert.Len(t, echo.diff, 0)
+	assert.JSONEq(t, `{"method": "POST", "token": "t", "type": "application/json", "body": {"name": "foo"}}`, echo.jsA)
+
+	// server, which is down
+	serverA.Close()
+	reports = replayReports(jf.NewDiffer(), serverB.Client(), serverA.URL, serverB.URL, reqs[:1])
+	require.Len(t, reports, 1)
+	assert.NotEmpty(t, reports[0].problem)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vyskocilm/jf"
+)
+
+// ruleFunc adds a rule with given argument to Differ
+type ruleFunc func(d *jf.Differ, arg string) error
+
+// selectorRule returns ruleFunc for rules with regexp argument only
+func selectorRule(add func(d *jf.Differ, selector *regexp.Regexp)) ruleFunc {
+	return func(d *jf.Differ, arg string) error {
+		rg, err := regexp.Compile(arg)
+		if err != nil {
+			return err
+		}
+		add(d, rg)
+		return nil
+	}
+}
+
+// floatTolerance parses path=eps and compares floats with absolute tolerance
+func floatTolerance(d *jf.Differ, arg string) error {
+	idx := strings.LastIndex(arg, "=")
+	if idx == -1 {
+		return fmt.Errorf("expected path=eps, got %q", arg)
+	}
+	rg, err := regexp.Compile(arg[:idx])
+	if err != nil {
+		return err
+	}
+	eps, err := strconv.ParseFloat(arg[idx+1:], 64)
+	if err != nil {
+		return err
+	}
+	if eps < 0 || math.IsNaN(eps) {
+		return fmt.Errorf("tolerance must be a positive number, got %q", arg[idx+1:])
+	}
+	d.AddFloatEqual(rg, func(a, b float64) bool {
+		return math.Abs(a-b) <= eps
+	})
+	return nil
+}
+
+// arrayKey parses path=key and matches elements of arrays by key
+func arrayKey(d *jf.Differ, arg string) error {
+	idx := strings.LastIndex(arg, "=")
+	if idx == -1 || idx == len(arg)-1 {
+		return fmt.Errorf("expected path=key, got %q", arg)
+	}
+	rg, err := regexp.Compile(arg[:idx])
+	if err != nil {
+		return err
+	}
+	d.AddArrayKey(rg, arg[idx+1:])
+	return nil
+}
+
+// schemaRules derives rules from JSON Schema file
+func schemaRules(d *jf.Differ, arg string) error {
+	s, err := jf.LoadSchema(arg)
+	if err != nil {
+		return err
+	}
+	d.AddSchemaRules(s)
+	return nil
+}
+
+// rules are commandline flags, which add rules to Differ
+var rules = []struct {
+	name  string
+	usage string
+	fn    ruleFunc
+}{
+	{"ignore", "ignore keys matching regexp in both inputs", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnore(jf.RuleAB, rg) })},
+	{"ignore-a", "ignore keys matching regexp in a.json", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnore(jf.RuleA, rg) })},
+	{"ignore-b", "ignore keys matching regexp in b.json", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnore(jf.RuleB, rg) })},
+	{"ignore-if-zero", "ignore keys matching regexp if value is zero in both inputs", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnoreIfZero(jf.RuleAB, rg) })},
+	{"ignore-if-zero-a", "ignore keys matching regexp if value is zero in a.json", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnoreIfZero(jf.RuleA, rg) })},
+	{"ignore-if-zero-b", "ignore keys matching regexp if value is zero in b.json", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnoreIfZero(jf.RuleB, rg) })},
+	{"coerce-null", "make null equal to zero value of keys matching regexp in both inputs", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddCoerceNull(jf.RuleAB, rg) })},
+	{"coerce-null-a", "make null equal to zero value of keys matching regexp in a.json", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddCoerceNull(jf.RuleA, rg) })},
+	{"coerce-null-b", "make null equal to zero value of keys matching regexp in b.json", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddCoerceNull(jf.RuleB, rg) })},
+	{"ignore-order", "ignore order of arrays matching regexp", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnoreOrder(rg) })},
+	{"string-number", "make \"1\" equal to 1 for keys matching regexp", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddStringNumber(rg) })},
+	{"placeholders", "treat values like \"{{uuid}}\" or \"{{number:>0}}\" of keys matching regexp in a.json as predicates", selectorRule(func(d *jf.Differ, rg *regexp.Regexp) { d.AddPlaceholders(jf.RuleA, rg) })},
+	{"float-tolerance", "compare floats matching path regexp with absolute tolerance, path=eps", floatTolerance},
+	{"array-key", "match elements of arrays matching path regexp by key instead of index, path=key", arrayKey},
+	{"schema-rules", "derive rules from JSON Schema file: uniqueItems, nullable, multipleOf, x-jf-array-key and x-jf-ignore", schemaRules},
+}
+
+// ruleFlag is a repeatable flag, which adds a rule to Differ
+type ruleFlag struct {
+	d  *jf.Differ
+	fn ruleFunc
+}
+
+func (f *ruleFlag) String() string {
+	return ""
+}
+
+func (f *ruleFlag) Set(value string) error {
+	return f.fn(f.d, value)
+}
+
+// addRule adds rule by its name, as used in commandline flags
+func addRule(d *jf.Differ, name, arg string) error {
+	for _, r := range rules {
+		if r.name == name {
+			return r.fn(d, arg)
+		}
+	}
+	return fmt.Errorf("unknown rule %q", name)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/vyskocilm/jf"
+)
+
+// schemaDiff compares two versions of JSON Schema and prints the changes
+//
+//	jf schema-diff old.schema.json new.schema.json
+//
+// It returns true if there is a breaking change.
+func schemaDiff(w io.Writer, d *jf.Differ, args []string, o *options) (bool, error) {
+	if len(args) != 2 {
+		return false, fmt.Errorf("schema-diff: expected old and new schema, got %d arguments", len(args))
+	}
+	jsA, jsB, err := js(args[0], args[1])
+	if err != nil {
+		return false, err
+	}
+	changes, err := d.DiffSchemas(jsA, jsB)
+	if err != nil {
+		return false, err
+	}
+	breaking := 0
+	for _, c := range changes {
+		if c.Breaking {
+			breaking++
+		}
+	}
+	if w == nil {
+		return breaking > 0, nil
+	}
+
+	switch o.format {
+	case "text":
+		err = writeSchemaChanges(w, changes, breaking, o)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		if changes == nil {
+			changes = []jf.SchemaChange{}
+		}
+		err = enc.Encode(changes)
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, c := range changes {
+			if err = enc.Encode(c); err != nil {
+				break
+			}
+		}
+	default:
+		err = fmt.Errorf("schema-diff: unsupported -format value %q, expected text, json or jsonl", o.format)
+	}
+	return breaking > 0, err
+}
+
+// writeSchemaChanges prints a table of changes and a summary
+func writeSchemaChanges(w io.Writer, changes []jf.SchemaChange, breaking int, o *options) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, c := range changes {
+		compat := o.color.green("non-breaking")
+		if c.Breaking {
+			compat = o.color.red("breaking")
+		}
+		a, b := c.A, c.B
+		if a == "" {
+			a = "-"
+		}
+		if b == "" {
+			b = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", compat, c.Selector, a, b, c.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "%d changes, %d breaking\n", len(changes), breaking)
+	return err
+}
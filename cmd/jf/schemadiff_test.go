@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vyskocilm/jf"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"v1.json": `{"properties": {"id": {"type": "string", "description": "id"}}}`,
+		"v2.json": `{"properties": {"id": {"type": "string", "description": "uuid", "pattern": "^[0-9a-f-]+$"}}}`,
+		"v3.json": `{"properties": {"id": {"type": "string", "description": "uuid"}}}`,
+	})
+	v1, v2, v3 := filepath.Join(dir, "v1.json"), filepath.Join(dir, "v2.json"), filepath.Join(dir, "v3.json")
+
+	var buf bytes.Buffer
+	breaking, err := schemaDiff(&buf, jf.NewDiffer(), []string{v1, v2}, &options{format: "text"})
+	require.NoError(t, err)
+	assert.True(t, breaking)
+	assert.Equal(t, []string{
+		`non-breaking properties.id.description "id" "uuid"         changed`,
+		`breaking     properties.id.pattern     -    "^[0-9a-f-]+$" pattern added`,
+		`2 changes, 1 breaking`,
+	}, strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"))
+
+	// pattern removed is fine
+	breaking, err = schemaDiff(nil, jf.NewDiffer(), []string{v2, v3}, &options{format: "text"})
+	require.NoError(t, err)
+	assert.False(t, breaking)
+
+	buf.Reset()
+	_, err = schemaDiff(&buf, jf.NewDiffer(), []string{v1, v1}, &options{format: "json"})
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", buf.String())
+
+	buf.Reset()
+	_, err = schemaDiff(&buf, jf.NewDiffer(), []string{v1, v2}, &options{format: "jsonl"})
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var change jf.SchemaChange
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &change))
+	assert.Equal(t, jf.SchemaChange{Selector: "properties.id.pattern", Breaking: true, Message: "pattern added", B: `"^[0-9a-f-]+$"`}, change)
+
+	_, err = schemaDiff(&buf, jf.NewDiffer(), []string{v1}, &options{format: "text"})
+	assert.EqualError(t, err, "schema-diff: expected old and new schema, got 1 arguments")
+	_, err = schemaDiff(&buf, jf.NewDiffer(), []string{v1, v2}, &options{format: "html"})
+	assert.EqualError(t, err, `schema-diff: unsupported -format value "html", expected text, json or jsonl`)
+}
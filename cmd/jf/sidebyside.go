@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultWidth = 80
+
+// terminalWidth returns width of output in columns. Explicit width wins,
+// then COLUMNS environment variable and then the size of a terminal
+func terminalWidth(width int, f *os.File) int {
+	if width > 0 {
+		return width
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if w := ttyWidth(f); w > 0 {
+		return w
+	}
+	return defaultWidth
+}
+
+// fit truncates or pads s to exactly width runes
+func fit(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		if width == 0 {
+			return ""
+		}
+		return string(r[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// writeSideBySide prints both texts in two columns like diff -y does. Removed
+// lines are red, added green and modified yellow
+func writeSideBySide(w io.Writer, nameA, nameB, textA, textB string, width int, c colorizer) {
+	a := splitLines(textA)
+	b := splitLines(textB)
+	ops := diffLines(a, b)
+
+	// left column | marker | right column
+	column := (width - 3) / 2
+	if column < 1 {
+		column = 1
+	}
+
+	line := func(left, marker, right string, color func(string) string) {
+		fmt.Fprintf(w, "%s %s %s\n",
+			color(fit(left, column)),
+			color(marker),
+			color(strings.TrimRight(fit(right, column), " ")))
+	}
+	plain := func(s string) string { return s }
+
+	line(nameA, " ", nameB, c.bold)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			line(a[ops[i].a], " ", b[ops[i].b], plain)
+			i++
+			continue
+		}
+		// a block of changes: pair removed lines with added as modifications
+		deleted := make([]string, 0)
+		inserted := make([]string, 0)
+		for ; i < len(ops) && ops[i].kind != opEqual; i++ {
+			if ops[i].kind == opDelete {
+				deleted = append(deleted, a[ops[i].a])
+			} else {
+				inserted = append(inserted, b[ops[i].b])
+			}
+		}
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			switch {
+			case j < len(deleted) && j < len(inserted):
+				line(deleted[j], "|", inserted[j], c.yellow)
+			case j < len(deleted):
+				line(deleted[j], "<", "", c.red)
+			default:
+				line("", ">", inserted[j], c.green)
+			}
+		}
+	}
+}
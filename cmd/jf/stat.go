@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vyskocilm/jf"
+)
+
+// failOnOnly is -fail-on value for files present on one side only
+const failOnOnly = "only"
+
+// failOn is a set of kinds of differences, which makes jf to exit with 1
+type failOn map[string]bool
+
+// allKinds are all valid -fail-on values
+var allKinds = []string{
+	jf.KindChanged.String(),
+	jf.KindAdded.String(),
+	jf.KindRemoved.String(),
+	jf.KindDuplicateKey.String(),
+	jf.KindSchema.String(),
+	failOnOnly,
+}
+
+// parseFailOn parses comma separated list of kinds
+func parseFailOn(value string) (failOn, error) {
+	ret := make(failOn)
+	for _, kind := range strings.Split(value, ",") {
+		kind = strings.TrimSpace(kind)
+		valid := false
+		for _, k := range allKinds {
+			if kind == k {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown -fail-on value %q, expected %s", kind, strings.Join(allKinds, ", "))
+		}
+		ret[kind] = true
+	}
+	return ret, nil
+}
+
+// fails returns true if report contains difference of a kind from the set
+func (f failOn) fails(r *report) bool {
+	if r.problem != "" {
+		return true
+	}
+	if r.only != "" {
+		return f[failOnOnly]
+	}
+	for _, p := range r.diff {
+		if f[p.Kind().String()] {
+			return true
+		}
+	}
+	return false
+}
+
+// failsAny returns true if any report fails
+func (f failOn) failsAny(reports []*report) bool {
+	for _, r := range reports {
+		if f.fails(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// earlyStop returns the number of differences Differ can stop at for
+// -max-diffs max. It is one more than max, so limitDiffs knows the output
+// was truncated. If fails does not contain all kinds, early stop is disabled,
+// because the exit status would depend on the differences, which were not
+// found yet
+func earlyStop(max int, fails failOn) int {
+	if max <= 0 {
+		return 0
+	}
+	for _, kind := range allKinds {
+		if !fails[kind] {
+			return 0
+		}
+	}
+	return max + 1
+}
+
+// limitDiffs keeps at most max differences in reports and drops reports
+// after the limit was reached. Returns true if something was dropped.
+func limitDiffs(reports []*report, max int) ([]*report, bool) {
+	if max <= 0 {
+		return reports, false
+	}
+	left := max
+	for idx, r := range reports {
+		if left == 0 {
+			return reports[:idx], true
+		}
+		if len(r.diff) > left {
+			r.diff = r.diff[:left]
+			return reports[:idx+1], true
+		}
+		left -= len(r.diff)
+	}
+	return reports, false
+}
+
+// kindCounts returns "3 changed, 1 added, 0 removed" for one report
+func kindCounts(diff jf.DiffList) string {
+	counts := make(map[jf.DiffKind]int)
+	for _, p := range diff {
+		counts[p.Kind()]++
+	}
+	parts := []string{
+		fmt.Sprintf("%d changed", counts[jf.KindChanged]),
+		fmt.Sprintf("%d added", counts[jf.KindAdded]),
+		fmt.Sprintf("%d removed", counts[jf.KindRemoved]),
+	}
+	if counts[jf.KindDuplicateKey] > 0 {
+		parts = append(parts, fmt.Sprintf("%d duplicate keys", counts[jf.KindDuplicateKey]))
+	}
+	if counts[jf.KindSchema] > 0 {
+		parts = append(parts, fmt.Sprintf("%d schema violations", counts[jf.KindSchema]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeStat prints number of differences per pair of files and a total, like
+// git diff --stat does
+func writeStat(w io.Writer, d *jf.Differ, reports []*report, o *options) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, r := range reports {
+		name := r.rel
+		if name == "" {
+			name = r.pathA + " " + r.pathB
+		}
+		if r.problem != "" {
+			fmt.Fprintf(tw, " %s\t| %s\n", name, o.color.red(r.problem))
+			continue
+		}
+		if r.only != "" {
+			fmt.Fprintf(tw, " %s\t| %s\n", name, o.color.red(r.onlyMessage()))
+			continue
+		}
+		fmt.Fprintf(tw, " %s\t| %d\t(%s)\n", name, len(r.diff), kindCounts(r.diff))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, " %d files, %s\n", len(reports), summary(reports))
+	return err
+}
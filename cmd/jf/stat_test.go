@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vyskocilm/jf"
+)
+
+func TestParseFailOn(t *testing.T) {
+	fails, err := parseFailOn("added, removed")
+	require.NoError(t, err)
+	assert.Equal(t, failOn{"added": true, "removed": true}, fails)
+
+	_, err = parseFailOn("added,foo")
+	assert.EqualError(t, err, `unknown -fail-on value "foo", expected changed, added, removed, duplicate-key, schema, only`)
+
+	reports := testReports(t)
+	fails, _ = parseFailOn("added")
+	assert.True(t, fails.fails(reports[0]))
+	assert.False(t, fails.fails(reports[1]))
+	assert.False(t, fails.fails(reports[2]))
+	// problem always fails
+	assert.True(t, fails.fails(reports[3]))
+	fails, _ = parseFailOn("only")
+	assert.False(t, fails.fails(reports[0]))
+	assert.True(t, fails.fails(reports[2]))
+	assert.True(t, fails.failsAny(reports[:3]))
+	assert.False(t, fails.failsAny(reports[:2]))
+}
+
+func TestEarlyStop(t *testing.T) {
+	all, err := parseFailOn("changed,added,removed,duplicate-key,schema,only")
+	require.NoError(t, err)
+	assert.Equal(t, 0, earlyStop(0, all))
+	assert.Equal(t, 4, earlyStop(3, all))
+	removed, err := parseFailOn("removed")
+	require.NoError(t, err)
+	assert.Equal(t, 0, earlyStop(3, removed))
+}
+
+func diffOf(t *testing.T, jsA, jsB string) jf.DiffList {
+	t.Helper()
+	diff, err := jf.Diff(jsA, jsB)
+	require.NoError(t, err)
+	return diff
+}
+
+func TestLimitDiffs(t *testing.T) {
+	three := func() []*report {
+		return []*report{
+			{pathA: "a1", diff: diffOf(t, `{"a": 1, "b": 1}`, `{"a": 2, "b": 2}`)},
+			{pathA: "a2", only: "A"},
+			{pathA: "a3", diff: diffOf(t, `{"a": 1}`, `{"a": 2}`)},
+		}
+	}
+
+	reports, limited := limitDiffs(three(), 0)
+	assert.Len(t, reports, 3)
+	assert.False(t, limited)
+
+	reports, limited = limitDiffs(three(), 3)
+	assert.Len(t, reports, 3)
+	assert.False(t, limited)
+
+	reports, limited = limitDiffs(three(), 2)
+	assert.Len(t, reports, 1)
+	assert.Len(t, reports[0].diff, 2)
+	assert.True(t, limited)
+
+	reports, limited = limitDiffs(three(), 1)
+	assert.Len(t, reports, 1)
+	assert.Len(t, reports[0].diff, 1)
+	assert.True(t, limited)
+}
+
+// TestMaxDiffsFailOn tests the exit status does not depend on -max-diffs
+// and the truncation is detected
+func TestMaxDiffsFailOn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"a.json": `{"a": 1, "b": 2, "c": 3}`,
+		"b.json": `{"a": 2, "c": 4}`,
+	})
+	args := []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}
+
+	testCases := []struct {
+		failOn  string
+		max     int
+		failed  bool
+		limited bool
+		shown   int
+	}{
+		{"removed", 1, true, true, 1},
+		{"added", 1, false, true, 1},
+		{"changed,added,removed,duplicate-key,schema,only", 1, true, true, 1},
+		{"changed,added,removed,duplicate-key,schema,only", 2, true, true, 2},
+		{"changed,added,removed,duplicate-key,schema,only", 3, true, false, 3},
+		{"removed", 0, true, false, 3},
+	}
+	for _, tc := range testCases {
+		fails, err := parseFailOn(tc.failOn)
+		require.NoError(t, err)
+		d := jf.NewDiffer().SetMaxDiffs(earlyStop(tc.max, fails))
+		reports, err := pairReports(d, args, earlyStop(tc.max, fails))
+		require.NoError(t, err)
+
+		failed := fails.failsAny(reports)
+		reports, limited := limitDiffs(reports, tc.max)
+		assert.Equal(t, tc.failed, failed, "%+v", tc)
+		assert.Equal(t, tc.limited, limited, "%+v", tc)
+		assert.Len(t, reports[0].diff, tc.shown, "%+v", tc)
+	}
+}
+
+func TestWriteStat(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeStat(&buf, jf.NewDiffer(), testReports(t), &options{format: "stat"}))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Regexp(t, `^ a.json b.json +\| 3 +\(1 changed, 1 added, 1 removed\)$`, lines[0])
+	assert.Regexp(t, `^ a2.json b2.json +\| 0 +\(0 changed, 0 added, 0 removed\)$`, lines[1])
+	assert.Regexp(t, `^ a3.json b3.json +\| only in a3.json$`, lines[2])
+	assert.Regexp(t, `^ a4.json b4.json +\| status 200 != 500$`, lines[3])
+	assert.Equal(t, " 4 files, 1 changed, 1 added, 1 removed, 1 files on one side only, 1 not compared", lines[4])
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package main
+
+import "os"
+
+// ttyWidth returns zero as terminal size detection is not supported
+func ttyWidth(f *os.File) int {
+	return 0
+}
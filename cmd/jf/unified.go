@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// lineOp is one step of edit script. a and b are line indexes in both
+// inputs, for insert a is the index where line would be inserted, for delete
+// b is the same
+type lineOp struct {
+	kind opKind
+	a    int
+	b    int
+}
+
+// splitLines splits text into lines without trailing newlines
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffCostLimit limits the number of differences searched for the middle
+// snake of one block. Beyond it the furthest reaching path splits the block,
+// so the edit script may be longer than the shortest one, but the time stays
+// reasonable for big inputs with many changes
+const diffCostLimit = 1024
+
+// myers computes the edit script in linear space by divide and conquer over
+// middle snakes, see "An O(ND) Difference Algorithm and Its Variations"
+type myers struct {
+	a, b    []string
+	ops     []lineOp
+	vf, vb  []int
+	offset  int
+	maxCost int
+}
+
+// diffLines returns the edit script transforming a to b using the Myers
+// algorithm. It is the shortest one unless there are more than
+// diffCostLimit differences in a block
+func diffLines(a, b []string) []lineOp {
+	return newMyers(a, b, diffCostLimit).diff()
+}
+
+func newMyers(a, b []string, maxCost int) *myers {
+	size := len(a) + len(b) + 2
+	return &myers{
+		a:       a,
+		b:       b,
+		ops:     make([]lineOp, 0, len(a)+len(b)),
+		vf:      make([]int, 2*size+1),
+		vb:      make([]int, 2*size+1),
+		offset:  size,
+		maxCost: maxCost,
+	}
+}
+
+func (m *myers) diff() []lineOp {
+	m.compare(0, len(m.a), 0, len(m.b))
+	return groupChanges(m.ops)
+}
+
+func (m *myers) equal(x, y int) {
+	m.ops = append(m.ops, lineOp{kind: opEqual, a: x, b: y})
+}
+
+// replace deletes a[aLo:aHi] and inserts b[bLo:bHi]
+func (m *myers) replace(aLo, aHi, bLo, bHi int) {
+	for x := aLo; x < aHi; x++ {
+		m.ops = append(m.ops, lineOp{kind: opDelete, a: x, b: bLo})
+	}
+	for y := bLo; y < bHi; y++ {
+		m.ops = append(m.ops, lineOp{kind: opInsert, a: aHi, b: y})
+	}
+}
+
+// compare appends the edit script of a[aLo:aHi] and b[bLo:bHi]
+func (m *myers) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && m.a[aLo] == m.b[bLo] {
+		m.equal(aLo, bLo)
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi && bLo < bHi && m.a[aHi-1] == m.b[bHi-1] {
+		aHi--
+		bHi--
+		suffix++
+	}
+
+	if aLo == aHi || bLo == bHi {
+		m.replace(aLo, aHi, bLo, bHi)
+	} else {
+		x, y, u, v := m.middleSnake(aLo, aHi, bLo, bHi)
+		if (x == aLo && y == bLo && u == aHi && v == bHi) || (u == aLo && v == bLo) || (x == aHi && y == bHi) {
+			// no progress, can't happen for the optimal split
+			m.replace(aLo, aHi, bLo, bHi)
+		} else {
+			m.compare(aLo, x, bLo, y)
+			for ; x < u; x, y = x+1, y+1 {
+				m.equal(x, y)
+			}
+			m.compare(u, aHi, v, bHi)
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		m.equal(aHi+i, bHi+i)
+	}
+}
+
+// middleSnake returns the start x, y and the end u, v of the middle snake of
+// the shortest edit script of a[aLo:aHi] and b[bLo:bHi]. Both must be non
+// empty. If the script is longer than maxCost, the furthest reaching forward
+// path is used as a split point instead
+func (m *myers) middleSnake(aLo, aHi, bLo, bHi int) (int, int, int, int) {
+	n, mm := aHi-aLo, bHi-bLo
+	delta := n - mm
+	odd := delta%2 != 0
+	vf, vb, off := m.vf, m.vb, m.offset
+	vf[off+1] = 0
+	vb[off+1] = 0
+
+	for d := 0; d <= (n+mm+1)/2; d++ {
+		if d > m.maxCost {
+			// too expensive, split at the furthest forward point of d-1
+			bestX, bestY := 0, 0
+			for k := -(d - 1); k <= d-1; k += 2 {
+				x := vf[off+k]
+				y := x - k
+				if x > n || y > mm || y < 0 {
+					continue
+				}
+				if x+y > bestX+bestY {
+					bestX, bestY = x, y
+				}
+			}
+			return aLo + bestX, bLo + bestY, aLo + bestX, bLo + bestY
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < mm && m.a[aLo+x] == m.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			// backward diagonal of the same line
+			kb := delta - k
+			if odd && kb >= -(d-1) && kb <= d-1 && x+vb[off+kb] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < mm && m.a[aHi-1-x] == m.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			kf := delta - k
+			if !odd && kf >= -d && kf <= d && x+vf[off+kf] >= n {
+				return aLo + n - x, bLo + mm - y, aLo + n - x0, bLo + mm - y0
+			}
+		}
+	}
+	// unreachable, the paths always meet
+	return aLo, bLo, aLo, bLo
+}
+
+// groupChanges reorders each block of changes, so deleted lines go before
+// inserted ones like diff -u prints them
+func groupChanges(ops []lineOp) []lineOp {
+	ret := make([]lineOp, 0, len(ops))
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			ret = append(ret, ops[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(ops) && ops[j].kind != opEqual {
+			j++
+		}
+		aStart, bStart := ops[i].a, ops[i].b
+		aEnd := aStart
+		for _, op := range ops[i:j] {
+			if op.kind == opDelete {
+				ret = append(ret, lineOp{kind: opDelete, a: op.a, b: bStart})
+				aEnd = op.a + 1
+			}
+		}
+		for _, op := range ops[i:j] {
+			if op.kind == opInsert {
+				ret = append(ret, lineOp{kind: opInsert, a: aEnd, b: op.b})
+			}
+		}
+		i = j
+	}
+	return ret
+}
+
+// hunks splits edit script into groups of changes with context lines around
+func hunks(ops []lineOp, context int) [][]lineOp {
+	ret := make([][]lineOp, 0)
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			j := end
+			for j < len(ops) && ops[j].kind == opEqual {
+				j++
+			}
+			if j == len(ops) || j-end > 2*context {
+				end += context
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = j
+		}
+		ret = append(ret, ops[start:end])
+		i = end
+	}
+	return ret
+}
+
+// hunkRange formats start,count part of hunk header the way diff -u does
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// writeUnified prints diff -u like output of two texts
+func writeUnified(w io.Writer, nameA, nameB, textA, textB string, context int, c colorizer) {
+	a := splitLines(textA)
+	b := splitLines(textB)
+	ops := diffLines(a, b)
+
+	fmt.Fprintf(w, "%s\n", c.bold("--- "+nameA))
+	fmt.Fprintf(w, "%s\n", c.bold("+++ "+nameB))
+	for _, hunk := range hunks(ops, context) {
+		countA, countB := 0, 0
+		for _, op := range hunk {
+			if op.kind != opInsert {
+				countA++
+			}
+			if op.kind != opDelete {
+				countB++
+			}
+		}
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(hunk[0].a, countA), hunkRange(hunk[0].b, countB))
+		fmt.Fprintf(w, "%s\n", c.cyan(header))
+		for _, op := range hunk {
+			switch op.kind {
+			case opEqual:
+				fmt.Fprintf(w, " %s\n", a[op.a])
+			case opDelete:
+				fmt.Fprintf(w, "%s\n", c.red("-"+a[op.a]))
+			case opInsert:
+				fmt.Fprintf(w, "%s\n", c.green("+"+b[op.b]))
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// applyOps checks the edit script is consistent and returns the number of
+// inserted and deleted lines
+func applyOps(t *testing.T, a, b []string, ops []lineOp) int {
+	t.Helper()
+	x, y, edits := 0, 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case opEqual:
+			if !assert.Equal(t, x, op.a) || !assert.Equal(t, y, op.b) || !assert.Equal(t, a[x], b[y]) {
+				return edits
+			}
+			x++
+			y++
+		case opDelete:
+			if !assert.Equal(t, x, op.a) || !assert.Equal(t, y, op.b) {
+				return edits
+			}
+			x++
+			edits++
+		case opInsert:
+			if !assert.Equal(t, x, op.a) || !assert.Equal(t, y, op.b) {
+				return edits
+			}
+			y++
+			edits++
+		}
+	}
+	assert.Equal(t, len(a), x)
+	assert.Equal(t, len(b), y)
+	return edits
+}
+
+// lcs returns the length of the longest common subsequence
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestDiffLines(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b []string
+	}{
+		{"empty", nil, nil},
+		{"empty a", nil, []string{"a", "b"}},
+		{"empty b", []string{"a", "b"}, nil},
+		{"equal", []string{"a", "b"}, []string{"a", "b"}},
+		{"all changed", []string{"a", "b", "c"}, []string{"x", "y"}},
+		{"insert in the middle", []string{"a", "c"}, []string{"a", "b", "c"}},
+		{"delete at the end", []string{"a", "b", "c"}, []string{"a", "b"}},
+		{"abcabba", strings.Split("abcabba", ""), strings.Split("cbabac", "")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ops := diffLines(tc.a, tc.b)
+			edits := applyOps(t, tc.a, tc.b, ops)
+			assert.Equal(t, len(tc.a)+len(tc.b)-2*lcs(tc.a, tc.b), edits)
+		})
+	}
+}
+
+// TestDiffLinesRandom compares the length of edit script with LCS
+func TestDiffLinesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	random := func() []string {
+		s := make([]string, r.Intn(30))
+		for i := range s {
+			s[i] = string(rune('a' + r.Intn(4)))
+		}
+		return s
+	}
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		edits := applyOps(t, a, b, diffLines(a, b))
+		assert.Equal(t, len(a)+len(b)-2*lcs(a, b), edits, "%v %v", a, b)
+
+		// the cost limit gives a valid, maybe longer, script
+		applyOps(t, a, b, newMyers(a, b, 1).diff())
+	}
+}
+
+// TestDiffLinesBig tests many changes in a big input finish in linear space
+func TestDiffLinesBig(t *testing.T) {
+	a := make([]string, 20000)
+	b := make([]string, 20000)
+	for i := range a {
+		a[i] = fmt.Sprintf(`"key%d": %d,`, i, i)
+		b[i] = a[i]
+		if i%3 == 1 {
+			b[i] = fmt.Sprintf(`"key%d": %d,`, i, -i)
+		}
+	}
+	edits := applyOps(t, a, b, diffLines(a, b))
+	assert.Equal(t, 2*6667, edits)
+}
+
+func TestGroupChanges(t *testing.T) {
+	a := []string{"a", "b", "c"}
+	b := []string{"x", "b", "y"}
+	ops := diffLines(a, b)
+	assert.Equal(t, []lineOp{
+		{kind: opDelete, a: 0, b: 0},
+		{kind: opInsert, a: 1, b: 0},
+		{kind: opEqual, a: 1, b: 1},
+		{kind: opDelete, a: 2, b: 2},
+		{kind: opInsert, a: 3, b: 2},
+	}, ops)
+}
+
+func TestHunks(t *testing.T) {
+	lines := func(n int, changed ...int) ([]string, []string) {
+		a := make([]string, n)
+		b := make([]string, n)
+		for i := range a {
+			a[i] = fmt.Sprint(i)
+			b[i] = a[i]
+		}
+		for _, i := range changed {
+			b[i] = "x"
+		}
+		return a, b
+	}
+
+	assert.Len(t, hunks(diffLines(nil, nil), 3), 0)
+
+	// gap of 2*context equal lines is merged into one hunk
+	a, b := lines(20, 5, 12)
+	h := hunks(diffLines(a, b), 3)
+	assert.Len(t, h, 1)
+	// 3 context + 2 changed + 6 equal + 2 changed + 3 context
+	assert.Len(t, h[0], 16)
+
+	// one more line splits it
+	a, b = lines(20, 5, 13)
+	h = hunks(diffLines(a, b), 3)
+	assert.Len(t, h, 2)
+	assert.Len(t, h[0], 8)
+	assert.Len(t, h[1], 8)
+
+	// context is cut at the beginning and the end
+	a, b = lines(3, 0, 2)
+	h = hunks(diffLines(a, b), 3)
+	assert.Len(t, h, 1)
+	assert.Len(t, h[0], 5)
+
+	// zero context
+	a, b = lines(5, 1, 3)
+	h = hunks(diffLines(a, b), 0)
+	assert.Len(t, h, 2)
+}
+
+func TestWriteUnified(t *testing.T) {
+	var buf bytes.Buffer
+	writeUnified(&buf, "a.json", "b.json", "{\n \"a\": 1,\n \"b\": 2\n}\n", "{\n \"a\": 1,\n \"b\": 3,\n \"c\": 4\n}\n", 1, colorizer(false))
+	assert.Equal(t, `--- a.json
++++ b.json
+@@ -2,3 +2,4 @@
+  "a": 1,
+- "b": 2
++ "b": 3,
++ "c": 4
+ }
+`, buf.String())
+
+	buf.Reset()
+	writeUnified(&buf, "a.json", "b.json", "", "{}\n", 3, colorizer(false))
+	assert.Equal(t, "--- a.json\n+++ b.json\n@@ -0,0 +1 @@\n+{}\n", buf.String())
+}
@@ -0,0 +1,135 @@
+package jf
+
+import (
+	"github.com/stretchr/objx"
+)
+
+// CompareMode says if jsonA and jsonB must be equal or if one contains the
+// other one
+type CompareMode int
+
+const (
+	// CompareExact reports all differences
+	CompareExact CompareMode = iota
+	// CompareSubset checks jsonA is contained in jsonB. Keys present in jsonB
+	// only are not reported and each element of an array of jsonA must be
+	// equal to some element of jsonB.
+	CompareSubset
+	// CompareSuperset checks jsonA contains jsonB, it is the mirror of
+	// CompareSubset
+	CompareSuperset
+)
+
+// SetCompareMode configures if Differ reports all differences or checks one
+// JSON contains the other one
+func (d *Differ) SetCompareMode(mode CompareMode) *Differ {
+	d.compareMode = mode
+	return d
+}
+
+// objxMapSlice converts []objx.Map to []interface{}
+func objxMapSlice(s []objx.Map) []interface{} {
+	ret := make([]interface{}, len(s))
+	for idx, m := range s {
+		ret[idx] = m
+	}
+	return ret
+}
+
+// maxMatching returns the maximum matching of a bipartite graph of inner and
+// outer elements found by augmenting paths. edges[i] are indexes of outer
+// elements equal to inner element i. Returns an inner index for each outer
+// element or -1 if it was not matched.
+func maxMatching(edges [][]int, outerLen int) []int {
+	matchOf := make([]int, outerLen)
+	for idx := range matchOf {
+		matchOf[idx] = -1
+	}
+	var augment func(i int, seen []bool) bool
+	augment = func(i int, seen []bool) bool {
+		for _, o := range edges[i] {
+			if seen[o] {
+				continue
+			}
+			seen[o] = true
+			if matchOf[o] == -1 || augment(matchOf[o], seen) {
+				matchOf[o] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range edges {
+		augment(i, make([]bool, outerLen))
+	}
+	return matchOf
+}
+
+// diffSliceContains diffs arrays in CompareSubset or CompareSuperset mode.
+// Each element of the contained array must be equal to a different element of
+// the other array, at any index. The elements are paired by a maximum
+// matching, so the order of arrays does not matter. Elements, which were not
+// matched, are diffed against the element on the same index if it was not
+// used, otherwise they are reported as removed (or added for
+// CompareSuperset).
+func (d *Differ) diffSliceContains(p path, sliceA, sliceB []interface{}) error {
+	// inner is contained in outer
+	inner, outer := sliceA, sliceB
+	pair := func(i, o interface{}) (*objx.Value, *objx.Value) { return newValue(i), newValue(o) }
+	if d.compareMode == CompareSuperset {
+		inner, outer = sliceB, sliceA
+		pair = func(i, o interface{}) (*objx.Value, *objx.Value) { return newValue(o), newValue(i) }
+	}
+
+	// edges[i] are outer elements equal to inner element i
+	edges := make([][]int, len(inner))
+	other := d.clone().SetMaxDiffs(1)
+	for idxI, i := range inner {
+		item := p.index(idxI)
+		for idxO, o := range outer {
+			other.diff = make(DiffList, 0, 1)
+			valueA, valueB := pair(i, o)
+			err := other.diffValues(item, valueA, valueB)
+			if err != nil && err != errMaxDiffs {
+				return err
+			}
+			if len(other.diff) == 0 {
+				edges[idxI] = append(edges[idxI], idxO)
+			}
+		}
+	}
+
+	matchedInner := newIntSet()
+	matchedOuter := newIntSet()
+	for idxO, idxI := range maxMatching(edges, len(outer)) {
+		if idxI != -1 {
+			matchedInner.Add(idxI)
+			matchedOuter.Add(idxO)
+		}
+	}
+
+	for idx, i := range inner {
+		if matchedInner.Has(idx) {
+			continue
+		}
+		if d.full() {
+			return errMaxDiffs
+		}
+		item := p.index(idx)
+		if idx < len(outer) && !matchedOuter.Has(idx) {
+			valueA, valueB := pair(i, outer[idx])
+			err := d.diffValues(item, valueA, valueB)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		floatEqualFunc := d.floatEqualFunc(item.selector)
+		if d.compareMode == CompareSuperset {
+			d.lineB(item, jsonI{i: i, floatEqualFunc: floatEqualFunc})
+		} else {
+			d.lineA(item, jsonI{i: i, floatEqualFunc: floatEqualFunc})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package jf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareSubset(t *testing.T) {
+	const jsonA = `{"id": 1, "tags": ["b"], "items": [{"id": 2}], "nested": {"a": 1}}`
+	const jsonB = `{"id": 1, "extra": true, "tags": ["a", "b", "c"], "items": [{"id": 1, "x": 1}, {"id": 2, "x": 2}], "nested": {"a": 1, "b": 2}}`
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().SetCompareMode(CompareSubset).Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+
+	// but not the other way
+	lines, err = NewDiffer().SetCompareMode(CompareSubset).Diff(jsonB, jsonA)
+	assert.NoError(err)
+	assert.Len(lines, 7)
+
+	// mirrored
+	lines, err = NewDiffer().SetCompareMode(CompareSuperset).Diff(jsonB, jsonA)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+}
+
+func TestCompareSubsetArrays(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDiffer().SetCompareMode(CompareSubset)
+
+	// each element must match a different one
+	lines, err := d.Diff(`{"a": [1, 1]}`, `{"a": [1, 2, 3]}`)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "a[1]", "1", "2")
+
+	// no counterpart on the same index
+	lines, err = d.Diff(`{"a": [1, 5, 4]}`, `{"a": [3, 1]}`)
+	assert.NoError(err)
+	assert.Len(lines, 2)
+	assertLine(t, lines[0], "a[1]", "5", "")
+	assert.Equal(KindRemoved, lines[0].Kind())
+	assertLine(t, lines[1], "a[2]", "4", "")
+
+	// objects not found are diffed by index
+	lines, err = d.Diff(`{"a": [{"id": 1, "v": 1}]}`, `{"a": [{"id": 1, "v": 2, "x": 0}]}`)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "a[0].v", "1", "2")
+
+	// superset reports missing elements of B as added
+	lines, err = NewDiffer().SetCompareMode(CompareSuperset).Diff(`{"a": [3, 1]}`, `{"a": [1, 5, 4]}`)
+	assert.NoError(err)
+	assert.Len(lines, 2)
+	assert.Equal(KindAdded, lines[0].Kind())
+	assertLine(t, lines[0], "a[1]", "", "5")
+}
+
+// TestCompareSubsetMatching tests the greedy pairing of {"x": 1} with
+// {"x": 1, "y": 2} does not hide the matching
+func TestCompareSubsetMatching(t *testing.T) {
+	const jsonA = `{"a": [{"x": 1}, {"x": 1, "y": 2}]}`
+	const jsonB = `{"a": [{"x": 1, "y": 2}, {"x": 1}]}`
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().SetCompareMode(CompareSubset).Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+
+	lines, err = NewDiffer().SetCompareMode(CompareSuperset).Diff(jsonB, jsonA)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+
+	lines, err = NewDiffer().SetCompareMode(CompareSubset).Diff(`{"a": [{"x": 1}, {"x": 1, "y": 2}, {"x": 1}]}`, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "a[2]", `{"x":1}`, "")
+}
+
+func TestMaxMatching(t *testing.T) {
+	testCases := []struct {
+		edges    [][]int
+		outerLen int
+		expected []int
+	}{
+		{[][]int{}, 2, []int{-1, -1}},
+		{[][]int{{0, 1}, {0}}, 2, []int{1, 0}},
+		{[][]int{{0}, {0}}, 2, []int{0, -1}},
+		{[][]int{{0, 1, 2}, {0}, {1}}, 3, []int{1, 2, 0}},
+		{[][]int{{}, {1}}, 2, []int{-1, 1}},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, maxMatching(tc.edges, tc.outerLen), "%v", tc.edges)
+	}
+}
@@ -0,0 +1,440 @@
+package jf
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonField is a struct field visible to JSON
+type jsonField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+}
+
+// parseTag splits `json:"name,opt1,opt2"` tag into name and options
+func parseTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+func hasOption(opts []string, opt string) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// structFields returns the list of fields in the same way encoding/json sees
+// them: unexported fields and fields tagged `json:"-"` are skipped, untagged
+// embedded structs are flattened into the parent and conflicting promoted
+// fields are resolved by dominance rules
+func structFields(typ reflect.Type) []jsonField {
+	all := collectFields(typ, nil, map[reflect.Type]bool{})
+
+	byName := make(map[string][]jsonField, len(all))
+	names := make([]string, 0, len(all))
+	for _, field := range all {
+		if _, found := byName[field.name]; !found {
+			names = append(names, field.name)
+		}
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	fields := make([]jsonField, 0, len(names))
+	for _, name := range names {
+		if field, ok := dominantField(byName[name]); ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// collectFields returns all fields including the promoted ones, visiting
+// tracks embedded types on the current path, so recursive embedding ends
+func collectFields(typ reflect.Type, index []int, visiting map[reflect.Type]bool) []jsonField {
+	if visiting[typ] {
+		return nil
+	}
+	visiting[typ] = true
+	defer delete(visiting, typ)
+
+	fields := make([]jsonField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		idx := append(append([]int{}, index...), i)
+
+		if sf.Anonymous {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if sf.PkgPath != "" && (sf.Type.Kind() == reflect.Ptr || ft.Kind() != reflect.Struct) {
+				// encoding/json ignores embedded pointers to unexported
+				// types and unexported non-struct types
+				continue
+			}
+			if name == "" && ft.Kind() == reflect.Struct {
+				fields = append(fields, collectFields(ft, idx, visiting)...)
+				continue
+			}
+		} else if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tagged := name != ""
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, jsonField{
+			name:      name,
+			index:     idx,
+			tagged:    tagged,
+			omitEmpty: hasOption(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+// dominantField returns the field, which wins among fields of the same name:
+// the shallowest one, if there are more of them then the only tagged one.
+// Otherwise the fields annihilate each other and none is visible to JSON
+func dominantField(fields []jsonField) (jsonField, bool) {
+	depth := len(fields[0].index)
+	for _, field := range fields[1:] {
+		if len(field.index) < depth {
+			depth = len(field.index)
+		}
+	}
+	var dominant []jsonField
+	for _, field := range fields {
+		if len(field.index) == depth {
+			dominant = append(dominant, field)
+		}
+	}
+	if len(dominant) == 1 {
+		return dominant[0], true
+	}
+	var tagged []jsonField
+	for _, field := range dominant {
+		if field.tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but returns false if
+// embedded pointer is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// normalize converts the output of json.Unmarshal into the form objx.FromJSON
+// returns: nested maps are objx.Map and float64 without fraction is an int
+func normalize(i interface{}) interface{} {
+	switch v := i.(type) {
+	case float64:
+		if float64(int(v)) == v {
+			return int(v)
+		}
+		return v
+	case map[string]interface{}:
+		m := make(objx.Map, len(v))
+		for key, value := range v {
+			m[key] = normalize(value)
+		}
+		return m
+	case objx.Map:
+		return normalize(map[string]interface{}(v))
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for idx, value := range v {
+			s[idx] = normalize(value)
+		}
+		return s
+	}
+	return i
+}
+
+// fromMarshaler uses json.Marshaler in order to get the JSON representation
+func fromMarshaler(m json.Marshaler) (interface{}, error) {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var i interface{}
+	err = json.Unmarshal(b, &i)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(i), nil
+}
+
+// mapKey converts map key to string in the same way encoding/json does
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if k.Type().Implements(textMarshalerType) {
+		b, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", k.Type())
+}
+
+// goPointer identifies a pointer, map or slice on the current path, the
+// length distinguishes a slice from its subslices
+type goPointer struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// goWalker converts Go values and detects cycles of pointers
+type goWalker struct {
+	seen map[goPointer]struct{}
+}
+
+// fromGo walks Go value via reflection and returns the same tree of values
+// objx.FromJSON creates. It honors `json` struct tags
+func fromGo(v reflect.Value) (interface{}, error) {
+	w := &goWalker{seen: make(map[goPointer]struct{})}
+	return w.fromGo(v)
+}
+
+// enter marks a pointer, map or slice as visited and fails if it is already
+// on the current path, leave must be called when the value is done
+func (w *goWalker) enter(v reflect.Value) (goPointer, error) {
+	p := goPointer{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		p.len = v.Len()
+	}
+	if _, found := w.seen[p]; found {
+		return p, fmt.Errorf("encountered a cycle via %s", v.Type())
+	}
+	w.seen[p] = struct{}{}
+	return p, nil
+}
+
+func (w *goWalker) leave(p goPointer) {
+	delete(w.seen, p)
+}
+
+func (w *goWalker) fromGo(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.Type().Implements(jsonMarshalerType) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, nil
+		}
+		return fromMarshaler(v.Interface().(json.Marshaler))
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(jsonMarshalerType) {
+		return fromMarshaler(v.Addr().Interface().(json.Marshaler))
+	}
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return w.fromGo(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		p, err := w.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer w.leave(p)
+		return w.fromGo(v.Elem())
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > math.MaxInt64 {
+			// the same value as parsed from the JSON number
+			return float64(u), nil
+		}
+		return int(u), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, v.Type().Bits())}
+		}
+		if v.Kind() == reflect.Float32 {
+			// format with 32 bit precision, so float32(1.1) is not 1.100000023841858
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+		}
+		return normalize(f), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Struct:
+		m := make(objx.Map)
+		for _, field := range structFields(v.Type()) {
+			fv, ok := fieldByIndex(v, field.index)
+			if !ok {
+				continue
+			}
+			if field.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			i, err := w.fromGo(fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.name, err)
+			}
+			m[field.name] = i
+		}
+		return m, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		p, err := w.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer w.leave(p)
+		m := make(objx.Map, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			i, err := w.fromGo(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			m[key] = i
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		if v.Kind() == reflect.Slice {
+			p, err := w.enter(v)
+			if err != nil {
+				return nil, err
+			}
+			defer w.leave(p)
+		}
+		s := make([]interface{}, v.Len())
+		for idx := 0; idx < v.Len(); idx++ {
+			i, err := w.fromGo(v.Index(idx))
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", idx, err)
+			}
+			s[idx] = i
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("Support for type %s is not implemented", v.Type())
+}
+
+// DiffGo returns a list of individual differences between two Go values. It
+// walks structs, maps, slices and pointers via reflection and uses the same
+// rules as Diff. Struct fields are named according to `json` tags, so
+// selectors are the same as if values were marshaled to JSON. Like
+// json.Marshal, unexported fields are skipped, numbers are compared as JSON
+// numbers, so uint64 above math.MaxInt64 is a float64, and NaN or infinity
+// fail with *json.UnsupportedValueError.
+func (d *Differ) DiffGo(a, b interface{}) (DiffList, error) {
+	iA, err := fromGo(reflect.ValueOf(a))
+	if err != nil {
+		return []SingleDiff{}, err
+	}
+	iB, err := fromGo(reflect.ValueOf(b))
+	if err != nil {
+		return []SingleDiff{}, err
+	}
+
+	d2 := d.clone()
+	if err := d2.initPlaceholders(iA, iB); err != nil {
+		return []SingleDiff{}, err
+	}
+	err = d2.diffValues(path{}, newValue(iA), newValue(iB))
+	if d2.full() {
+		return d2.diff[:d2.maxDiffs], nil
+	}
+	if err != nil {
+		return d2.diff, err
+	}
+	return d2.diff, nil
+}
+
+// DiffGo is a shortcut for NewDiffer().DiffGo, exact diffing without any filters
+func DiffGo(a, b interface{}) (DiffList, error) {
+	return NewDiffer().DiffGo(a, b)
+}
@@ -0,0 +1,216 @@
+package jf
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+type goAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type goBase struct {
+	ID int `json:"id"`
+}
+
+type goPerson struct {
+	goBase
+	Name     string            `json:"name"`
+	Age      int               `json:"age,omitempty"`
+	Score    float64           `json:"score"`
+	Tags     []string          `json:"tags"`
+	Address  *goAddress        `json:"address"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created"`
+	Internal string            `json:"-"`
+	NoTag    bool
+	private  string
+}
+
+// TestDiffGo tests diffing of Go structs with json tags
+func TestDiffGo(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := goPerson{
+		goBase:   goBase{ID: 1},
+		Name:     "joe",
+		Score:    1.5,
+		Tags:     []string{"a", "b"},
+		Address:  &goAddress{Street: "Main"},
+		Created:  created,
+		Internal: "a",
+		private:  "a",
+	}
+	b := goPerson{
+		goBase:   goBase{ID: 2},
+		Name:     "joe",
+		Age:      42,
+		Score:    1.5,
+		Tags:     []string{"a", "c"},
+		Address:  &goAddress{Street: "Main", City: "Prague"},
+		Created:  created.Add(time.Second),
+		Internal: "b",
+		NoTag:    true,
+		private:  "b",
+	}
+
+	assert := assert.New(t)
+	lines, err := DiffGo(a, &b)
+	assert.NoError(err)
+	assert.Len(lines, 6)
+
+	assertLine(t, lines[0], "NoTag", "false", "true")
+	assertLine(t, lines[1], "address.city", "", `"Prague"`)
+	assertLine(t, lines[2], "created", `"2020-01-02T03:04:05Z"`, `"2020-01-02T03:04:06Z"`)
+	assertLine(t, lines[3], "id", "1", "2")
+	assertLine(t, lines[4], "tags[1]", `"b"`, `"c"`)
+	assertLine(t, lines[5], "age", "", "42")
+}
+
+// TestDiffGoRules tests the rules are applied on Go values too
+func TestDiffGoRules(t *testing.T) {
+	a := map[string]interface{}{
+		"list":    []int{1, 2, 3},
+		"float":   float32(1.1),
+		"pointer": (*goAddress)(nil),
+	}
+	b := map[string]interface{}{
+		"list":    []int{3, 2, 1},
+		"float":   1.1,
+		"pointer": nil,
+	}
+
+	assert := assert.New(t)
+	lines, err := DiffGo(a, b)
+	assert.NoError(err)
+	assert.Len(lines, 2)
+
+	lines, err = NewDiffer().AddIgnoreOrder(re(t, "list")).DiffGo(a, b)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+}
+
+// TestDiffGoUnsupported tests values without JSON representation
+func TestDiffGoUnsupported(t *testing.T) {
+	a := map[string]interface{}{"ch": make(chan int)}
+	_, err := DiffGo(a, a)
+	assert.Error(t, err)
+
+	for _, f := range []interface{}{math.NaN(), math.Inf(1), float32(math.Inf(-1))} {
+		_, err = DiffGo(map[string]interface{}{"f": f}, nil)
+		var uerr *json.UnsupportedValueError
+		assert.True(t, errors.As(err, &uerr), "%v", f)
+	}
+}
+
+// TestDiffGoUint64 tests big unsigned numbers do not wrap around
+func TestDiffGoUint64(t *testing.T) {
+	assert := assert.New(t)
+	lines, err := DiffGo([]uint64{math.MaxUint64, 1}, []int64{-1, 1})
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "[0]", "18446744073709552000", "-1")
+
+	lines, err = NewDiffer().DiffGo([]uint64{math.MaxUint64}, json.RawMessage(`[18446744073709551615]`))
+	assert.NoError(err)
+	assert.Len(lines, 0)
+}
+
+type goNode struct {
+	Name string  `json:"name"`
+	Next *goNode `json:"next"`
+}
+
+// TestDiffGoCycle tests cycles of pointers are reported as an error
+func TestDiffGoCycle(t *testing.T) {
+	node := &goNode{Name: "a"}
+	node.Next = node
+	_, err := DiffGo(node, node)
+	assert.EqualError(t, err, "next: encountered a cycle via *jf.goNode")
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	_, err = DiffGo(m, m)
+	assert.Error(t, err)
+
+	// the same pointer twice is not a cycle
+	shared := &goAddress{Street: "Main"}
+	lines, err := DiffGo([]*goAddress{shared, shared}, []*goAddress{shared, shared})
+	assert.NoError(t, err)
+	assert.Len(t, lines, 0)
+}
+
+type goName struct {
+	Name string
+	Tag  string
+}
+
+type goTitle struct {
+	Name string
+	Tag  string
+}
+
+type goLabel struct {
+	Label string `json:"Tag"`
+}
+
+type goDeep struct {
+	goLabel
+}
+
+type goConflict struct {
+	goName
+	goTitle
+	goDeep
+	Title string `json:"Name"`
+}
+
+type goTagged struct {
+	goName
+	goLabel
+}
+
+type goRecursive struct {
+	*goRecursive
+	ID int `json:"id"`
+}
+
+// TestDiffGoDominance tests promoted fields are resolved like encoding/json does
+func TestDiffGoDominance(t *testing.T) {
+	for _, v := range []interface{}{
+		goConflict{goName{"a", "b"}, goTitle{"c", "d"}, goDeep{goLabel{"e"}}, "f"},
+		goTagged{goName{"a", "b"}, goLabel{"c"}},
+		goRecursive{ID: 1},
+	} {
+		b, err := json.Marshal(v)
+		assert.NoError(t, err)
+		i, err := fromGo(reflect.ValueOf(v))
+		assert.NoError(t, err)
+		lines, err := NewDiffer().Diff(string(b), i.(objx.Map).MustJSON())
+		assert.NoError(t, err)
+		assert.Len(t, lines, 0, "%s", b)
+	}
+}
+
+// TestDiffGoMaxDiffs tests the limit of differences applies on Go values
+func TestDiffGoMaxDiffs(t *testing.T) {
+	a := []int{1, 2, 3, 4}
+	b := []int{5, 6, 7, 8}
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().SetMaxDiffs(2).DiffGo(a, b)
+	assert.NoError(err)
+	assert.Len(lines, 2)
+	assertLine(t, lines[1], "[1]", "2", "6")
+
+	lines, err = NewDiffer().SetMaxDiffs(100).DiffGo(a, b)
+	assert.NoError(err)
+	assert.Len(lines, 4)
+}
@@ -0,0 +1,60 @@
+package jf
+
+import (
+	"fmt"
+
+	"github.com/stretchr/objx"
+)
+
+// keepIgnored returns valueNew where values of keys ignored by rules are
+// replaced by valueOld ones
+func (d *Differ) keepIgnored(selector string, valueOld, valueNew interface{}) interface{} {
+	switch n := valueNew.(type) {
+	case objx.Map:
+		o, _ := valueOld.(objx.Map)
+		ret := make(objx.Map, len(n))
+		for key, value := range n {
+			keySelector := joinSelectors(selector, key)
+			ignoreA, ignoreB := d.shouldIgnore(keySelector)
+			if _, found := o[key]; found && (ignoreA || ignoreB) {
+				ret[key] = o[key]
+				continue
+			}
+			ret[key] = d.keepIgnored(keySelector, o[key], value)
+		}
+		for key, value := range o {
+			if ignoreA, ignoreB := d.shouldIgnore(joinSelectors(selector, key)); ignoreA || ignoreB {
+				ret[key] = value
+			}
+		}
+		return ret
+	case []interface{}:
+		o, _ := valueOld.([]interface{})
+		ret := make([]interface{}, len(n))
+		for idx, value := range n {
+			var old interface{}
+			if idx < len(o) {
+				old = o[idx]
+			}
+			ret[idx] = d.keepIgnored(joinSelectors(selector, fmt.Sprintf("[%d]", idx)), old, value)
+		}
+		return ret
+	}
+	return valueNew
+}
+
+// KeepIgnored returns jsonNew pretty printed with sorted keys, where keys
+// ignored by rules have values of jsonOld. Ignored keys missing in jsonOld
+// keep the new value. It allows to update expected data, typically golden
+// files, without a churn of volatile fields like timestamps.
+func (d *Differ) KeepIgnored(jsonOld, jsonNew string) (string, error) {
+	docOld, err := parseDocument(jsonOld)
+	if err != nil {
+		return "", fmt.Errorf("jsonOld: %w", err)
+	}
+	docNew, err := parseDocument(jsonNew)
+	if err != nil {
+		return "", fmt.Errorf("jsonNew: %w", err)
+	}
+	return prettyJSON(d.keepIgnored("", docOld.root, docNew.root))
+}
@@ -0,0 +1,39 @@
+package jf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestKeepIgnored tests ignored keys are taken from the old JSON
+func TestKeepIgnored(t *testing.T) {
+	const jsonOld = `{"id": 1, "name": "foo", "items": [{"created": "old", "v": 1}], "gone": true}`
+	const jsonNew = `{"id": 2, "name": "bar", "items": [{"created": "new", "v": 2}, {"created": "new", "v": 3}], "new": 1}`
+
+	assert := assert.New(t)
+	pretty, err := NewDiffer().
+		AddIgnore(RuleAB, re(t, `^id$`)).
+		AddIgnore(RuleB, re(t, `created$`)).
+		KeepIgnored(jsonOld, jsonNew)
+	assert.NoError(err)
+	assert.Equal(`{
+  "id": 1,
+  "items": [
+    {
+      "created": "old",
+      "v": 2
+    },
+    {
+      "created": "new",
+      "v": 3
+    }
+  ],
+  "name": "bar",
+  "new": 1
+}
+`, pretty)
+
+	_, err = NewDiffer().KeepIgnored(`{`, jsonNew)
+	assert.Error(err)
+}
@@ -0,0 +1,86 @@
+package jftest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/vyskocilm/jf"
+)
+
+// UpdateEnv is an environment variable, which enables the update of golden
+// files the same way -jftest.update flag does
+const UpdateEnv = "JF_UPDATE_GOLDEN"
+
+// update is namespaced, so it does not clash with -update flag defined by
+// tests importing jftest
+var update = flag.Bool("jftest.update", false, "update golden files compared by jftest.Golden")
+
+// updating returns true if golden files should be rewritten
+func updating() bool {
+	if *update {
+		return true
+	}
+	value := os.Getenv(UpdateEnv)
+	return value != "" && value != "0" && value != "false"
+}
+
+// Golden compares actual with JSON stored in path using the rules. Run the
+// test with -jftest.update flag or JF_UPDATE_GOLDEN=1 to write actual to the file
+// instead. When updating, fields matched by ignore rules keep the values of
+// the old golden file, so volatile fields do not change on every update.
+// Returns true if actual matches or the file was updated.
+func Golden(t TestingT, path string, actual interface{}, opts ...Option) bool {
+	t.Helper()
+	jsonActual, err := jf.ToJSON(actual)
+	if err != nil {
+		t.Errorf("actual: %s", err)
+		return false
+	}
+
+	old, err := ioutil.ReadFile(path)
+	if updating() {
+		if os.IsNotExist(err) {
+			old = []byte("{}")
+		} else if err != nil {
+			t.Errorf("golden file: %s", err)
+			return false
+		}
+		return writeGolden(t, path, string(old), jsonActual, opts)
+	}
+	if err != nil {
+		t.Errorf("golden file: %s, run with -jftest.update or %s=1 to create it", err, UpdateEnv)
+		return false
+	}
+
+	msg := compare(old, jsonActual, opts)
+	if msg != "" {
+		t.Errorf("%s: %s", path, msg)
+		return false
+	}
+	return true
+}
+
+func writeGolden(t TestingT, path, old, actual string, opts []Option) bool {
+	t.Helper()
+	d := differ(opts)
+	pretty, err := d.KeepIgnored(old, actual)
+	if err != nil {
+		// broken golden file has no values to keep, write actual only
+		pretty, err = d.KeepIgnored("{}", actual)
+	}
+	if err != nil {
+		t.Errorf("%s: %s", path, err)
+		return false
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Errorf("golden file: %s", err)
+		return false
+	}
+	if err := ioutil.WriteFile(path, []byte(pretty), 0644); err != nil {
+		t.Errorf("golden file: %s", err)
+		return false
+	}
+	return true
+}
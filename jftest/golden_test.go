@@ -0,0 +1,68 @@
+package jftest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// update is defined by many tests, jftest must not redefine it
+var _ = flag.Bool("update", false, "update test files")
+
+func TestUpdateFlag(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(updating())
+	assert.NoError(flag.Set("jftest.update", "true"))
+	defer flag.Set("jftest.update", "false")
+	assert.True(updating())
+}
+
+func TestGolden(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "jftest")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "x.golden.json")
+
+	// missing golden file
+	ft := &fakeT{}
+	assert.False(Golden(ft, path, `{"a": 1}`))
+	assert.Len(ft.errors, 1)
+
+	// create it
+	os.Setenv(UpdateEnv, "1")
+	defer os.Unsetenv(UpdateEnv)
+	ft = &fakeT{}
+	assert.True(Golden(ft, path, `{"time": "12:00", "a": 1}`, Ignore(`^time$`)))
+	assert.Len(ft.errors, 0)
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("{\n  \"a\": 1,\n  \"time\": \"12:00\"\n}\n", string(b))
+
+	// update keeps ignored field
+	assert.True(Golden(ft, path, `{"time": "13:00", "a": 2}`, Ignore(`^time$`)))
+	b, err = ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("{\n  \"a\": 2,\n  \"time\": \"12:00\"\n}\n", string(b))
+
+	// invalid golden file is overwritten
+	assert.NoError(ioutil.WriteFile(path, []byte(`{"a": `), 0644))
+	assert.True(Golden(ft, path, `{"time": "15:00", "a": 2}`, Ignore(`^time$`)))
+	assert.Len(ft.errors, 0)
+	b, err = ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("{\n  \"a\": 2,\n  \"time\": \"15:00\"\n}\n", string(b))
+	assert.NoError(ioutil.WriteFile(path, []byte(`{"a": 2, "time": "12:00"}`), 0644))
+
+	// compare
+	os.Unsetenv(UpdateEnv)
+	assert.True(Golden(ft, path, map[string]interface{}{"a": 2, "time": "14:00"}, Ignore(`^time$`)))
+	assert.Len(ft.errors, 0)
+	assert.False(Golden(ft, path, `{"a": 3, "time": "12:00"}`))
+	assert.Len(ft.errors, 1)
+	assert.Contains(ft.errors[0], "x.golden.json: JSON not equal: 1 differences")
+}
@@ -0,0 +1,117 @@
+package jftest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+)
+
+// Response is an expected HTTP response. Zero values are not checked
+type Response struct {
+	// Status is the expected status code
+	Status int
+	// Header contains selected headers, other headers of the response are
+	// not checked
+	Header http.Header
+	// ContentType is compared as a media type, so parameters like charset
+	// are checked only if present here
+	ContentType string
+	// Body is the expected JSON, see AssertJSONEqual for supported types
+	Body interface{}
+}
+
+// mediaTypeEqual compares Content-Type values, parameters of actual are
+// ignored unless expected has them
+func mediaTypeEqual(expected, actual string) bool {
+	typeE, paramsE, err := mime.ParseMediaType(expected)
+	if err != nil {
+		return expected == actual
+	}
+	typeA, paramsA, err := mime.ParseMediaType(actual)
+	if err != nil || typeE != typeA {
+		return false
+	}
+	for key, value := range paramsE {
+		if !strings.EqualFold(paramsA[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareResponse returns a list of problems of actual response
+func compareResponse(expected Response, resp *http.Response, opts []Option) []string {
+	problems := make([]string, 0)
+	if expected.Status != 0 && expected.Status != resp.StatusCode {
+		problems = append(problems, fmt.Sprintf("status: expected %d, got %d", expected.Status, resp.StatusCode))
+	}
+	if expected.ContentType != "" {
+		actual := resp.Header.Get("Content-Type")
+		if !mediaTypeEqual(expected.ContentType, actual) {
+			problems = append(problems, fmt.Sprintf("Content-Type: expected %q, got %q", expected.ContentType, actual))
+		}
+	}
+	keys := make([]string, 0, len(expected.Header))
+	for key := range expected.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		values := expected.Header[key]
+		actual := resp.Header.Values(key)
+		if strings.Join(values, ", ") != strings.Join(actual, ", ") {
+			problems = append(problems, fmt.Sprintf("header %s: expected %q, got %q", http.CanonicalHeaderKey(key), values, actual))
+		}
+	}
+	if expected.Body != nil {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return append(problems, fmt.Sprintf("body: %s", err))
+		}
+		// allow callers to read the body again
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if msg := compare(expected.Body, body, opts); msg != "" {
+			problems = append(problems, "body: "+msg)
+		}
+	}
+	return problems
+}
+
+// AssertResponse checks status, headers, Content-Type and JSON body of
+// resp. All the problems are reported at once. Rules apply to the body only.
+func AssertResponse(t TestingT, expected Response, resp *http.Response, opts ...Option) bool {
+	t.Helper()
+	problems := compareResponse(expected, resp, opts)
+	if len(problems) > 0 {
+		t.Errorf("HTTP response differs:\n%s", strings.Join(problems, "\n"))
+		return false
+	}
+	return true
+}
+
+// RequireResponse is like AssertResponse, but stops the test on failure
+func RequireResponse(t TestingT, expected Response, resp *http.Response, opts ...Option) {
+	t.Helper()
+	if !AssertResponse(t, expected, resp, opts...) {
+		t.FailNow()
+	}
+}
+
+// AssertRecorder is AssertResponse for responses recorded by httptest
+func AssertRecorder(t TestingT, expected Response, rec *httptest.ResponseRecorder, opts ...Option) bool {
+	t.Helper()
+	return AssertResponse(t, expected, rec.Result(), opts...)
+}
+
+// RequireRecorder is like AssertRecorder, but stops the test on failure
+func RequireRecorder(t TestingT, expected Response, rec *httptest.ResponseRecorder, opts ...Option) {
+	t.Helper()
+	if !AssertRecorder(t, expected, rec, opts...) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,67 @@
+package jftest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Request-Id", "42")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprint(w, `{"id": 7, "name": "foo", "created": "2020-01-01T00:00:00Z"}`)
+}
+
+func TestAssertRecorder(t *testing.T) {
+	assert := assert.New(t)
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("POST", "/users", nil))
+
+	ft := &fakeT{}
+	assert.True(AssertRecorder(ft, Response{
+		Status:      http.StatusCreated,
+		Header:      http.Header{"X-Request-Id": {"42"}},
+		ContentType: "application/json",
+		Body:        `{"id": 7, "name": "foo"}`,
+	}, rec, Ignore(`^created$`)), ft.errors)
+
+	assert.False(AssertRecorder(ft, Response{
+		Status:      http.StatusOK,
+		Header:      http.Header{"x-request-id": {"43"}},
+		ContentType: "text/html",
+		Body:        map[string]interface{}{"id": 8, "name": "foo"},
+	}, rec, Ignore(`^created$`)))
+	assert.Equal([]string{`HTTP response differs:
+status: expected 200, got 201
+Content-Type: expected "text/html", got "application/json; charset=utf-8"
+header X-Request-Id: expected ["43"], got ["42"]
+body: JSON not equal: 1 differences
+  selector  expected  actual
+  id        8         7`}, ft.errors)
+}
+
+func TestAssertResponse(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	ft := &fakeT{}
+	RequireResponse(ft, Response{
+		Status:      http.StatusCreated,
+		ContentType: "application/json; charset=UTF-8",
+		Body:        `{"id": 7, "name": "foo", "created": "2020-01-01T00:00:00Z"}`,
+	}, resp)
+	assert.False(ft.failed, ft.errors)
+
+	// body can be read again
+	RequireResponse(ft, Response{Body: `{}`}, resp)
+	assert.True(ft.failed)
+}
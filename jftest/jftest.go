@@ -0,0 +1,172 @@
+// Package jftest provides assertions comparing JSON documents in Go tests
+// using the jf Differ
+//
+//	func TestHandler(t *testing.T) {
+//		...
+//		jftest.AssertJSONEqual(t, `{"id": 1, "name": "foo"}`, rec.Body.String(),
+//			jftest.Ignore(`^created$`),
+//			jftest.IgnoreOrder(`tags`),
+//		)
+//	}
+package jftest
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/vyskocilm/jf"
+)
+
+// TestingT is the subset of testing.TB used by assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	FailNow()
+}
+
+type config struct {
+	differ *jf.Differ
+	rules  []func(d *jf.Differ)
+}
+
+// Option configures the Differ used for a comparison
+type Option func(c *config)
+
+// WithDiffer uses configured Differ. Rules passed as other options are
+// added to its copy, so d is never modified.
+func WithDiffer(d *jf.Differ) Option {
+	return func(c *config) {
+		c.differ = d
+	}
+}
+
+// selectorOption returns Option, which adds a rule for every selector regexp
+func selectorOption(add func(d *jf.Differ, rg *regexp.Regexp), selectors []string) Option {
+	return func(c *config) {
+		for _, selector := range selectors {
+			rg := regexp.MustCompile(selector)
+			c.rules = append(c.rules, func(d *jf.Differ) { add(d, rg) })
+		}
+	}
+}
+
+// Ignore ignores keys matching selector regexps in both expected and actual
+func Ignore(selectors ...string) Option {
+	return selectorOption(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnore(jf.RuleAB, rg) }, selectors)
+}
+
+// IgnoreIfZero ignores keys matching selector regexps if value is zero
+func IgnoreIfZero(selectors ...string) Option {
+	return selectorOption(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnoreIfZero(jf.RuleAB, rg) }, selectors)
+}
+
+// CoerceNull makes null equal to zero value for keys matching selector regexps
+func CoerceNull(selectors ...string) Option {
+	return selectorOption(func(d *jf.Differ, rg *regexp.Regexp) { d.AddCoerceNull(jf.RuleAB, rg) }, selectors)
+}
+
+// IgnoreOrder ignores order of arrays matching selector regexps
+func IgnoreOrder(selectors ...string) Option {
+	return selectorOption(func(d *jf.Differ, rg *regexp.Regexp) { d.AddIgnoreOrder(rg) }, selectors)
+}
+
+// StringNumber makes "1" equal to 1 for keys matching selector regexps
+func StringNumber(selectors ...string) Option {
+	return selectorOption(func(d *jf.Differ, rg *regexp.Regexp) { d.AddStringNumber(rg) }, selectors)
+}
+
+// FloatTolerance compares floats matching selector regexp with absolute tolerance
+func FloatTolerance(selector string, eps float64) Option {
+	return selectorOption(func(d *jf.Differ, rg *regexp.Regexp) {
+		d.AddFloatEqual(rg, func(a, b float64) bool { return math.Abs(a-b) <= eps })
+	}, []string{selector})
+}
+
+// Placeholders makes values like "{{uuid}}", "{{any}}" or "{{number:>0}}" of
+// expected to be predicates, see jf.Differ.AddPlaceholders
+func Placeholders() Option {
+	return selectorOption(func(d *jf.Differ, rg *regexp.Regexp) { d.AddPlaceholders(jf.RuleA, rg) }, []string{".*"})
+}
+
+// SchemaRules adds rules derived from the schema, see
+// jf.Differ.AddSchemaRules
+func SchemaRules(s *jf.Schema) Option {
+	return func(c *config) {
+		c.rules = append(c.rules, func(d *jf.Differ) { d.AddSchemaRules(s) })
+	}
+}
+
+// Subset checks expected is contained in actual, so extra keys of actual are
+// not reported and elements of expected arrays can be anywhere in actual ones
+func Subset() Option {
+	return func(c *config) {
+		c.rules = append(c.rules, func(d *jf.Differ) { d.SetCompareMode(jf.CompareSubset) })
+	}
+}
+
+// differ returns Differ with all the rules applied
+func differ(opts []Option) *jf.Differ {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	d := jf.NewDiffer()
+	if c.differ != nil {
+		d = c.differ.Clone()
+	}
+	for _, rule := range c.rules {
+		rule(d)
+	}
+	return d
+}
+
+// Report formats differences as a multi-line table of selectors, expected
+// and actual values, see jf.DiffList.Report
+func Report(diff jf.DiffList) string {
+	return diff.Report()
+}
+
+// compare diffs expected and actual and returns failure message or an empty
+// string if they are equal
+func compare(expected, actual interface{}, opts []Option) string {
+	jsonE, err := jf.ToJSON(expected)
+	if err != nil {
+		return fmt.Sprintf("expected: %s", err)
+	}
+	jsonA, err := jf.ToJSON(actual)
+	if err != nil {
+		return fmt.Sprintf("actual: %s", err)
+	}
+	diff, err := differ(opts).Diff(jsonE, jsonA)
+	if err != nil {
+		return strings.Replace(strings.Replace(err.Error(), "jsonA: ", "expected: ", 1), "jsonB: ", "actual: ", 1)
+	}
+	if len(diff) == 0 {
+		return ""
+	}
+	return "JSON not equal: " + Report(diff)
+}
+
+// AssertJSONEqual checks expected and actual JSON are equal using the rules
+// and reports the differences. Expected and actual can be string, []byte,
+// json.RawMessage or any value encoding/json can marshal. Returns true if
+// they are equal.
+func AssertJSONEqual(t TestingT, expected, actual interface{}, opts ...Option) bool {
+	t.Helper()
+	msg := compare(expected, actual, opts)
+	if msg != "" {
+		t.Errorf("%s", msg)
+		return false
+	}
+	return true
+}
+
+// RequireJSONEqual is like AssertJSONEqual, but stops the test on failure
+func RequireJSONEqual(t TestingT, expected, actual interface{}, opts ...Option) {
+	t.Helper()
+	if !AssertJSONEqual(t, expected, actual, opts...) {
+		t.FailNow()
+	}
+}
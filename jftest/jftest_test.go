@@ -0,0 +1,104 @@
+package jftest
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vyskocilm/jf"
+)
+
+// fakeT records failures instead of failing the test
+type fakeT struct {
+	errors []string
+	failed bool
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) FailNow() {
+	t.failed = true
+}
+
+func TestAssertJSONEqual(t *testing.T) {
+	assert := assert.New(t)
+
+	ft := &fakeT{}
+	assert.True(AssertJSONEqual(ft, `{"a": 1, "list": [1, 2]}`, []byte(`{"list": [1, 2], "a": 1}`)))
+	assert.Len(ft.errors, 0)
+
+	ft = &fakeT{}
+	assert.False(AssertJSONEqual(ft, `{"a": 1, "b": "x", "c": true}`, `{"a": 2, "c": true, "d": null}`))
+	assert.Equal([]string{`JSON not equal: 3 differences
+  selector  expected   actual
+  a         1          2
+  b         "x"        (missing)
+  d         (missing)  null`}, ft.errors)
+	assert.False(ft.failed)
+
+	ft = &fakeT{}
+	assert.False(AssertJSONEqual(ft, `{"a": 1`, `{}`))
+	assert.Len(ft.errors, 1)
+	assert.Contains(ft.errors[0], "expected: ")
+}
+
+func TestAssertJSONEqualOptions(t *testing.T) {
+	const expected = `{"id": 1, "tags": ["a", "b"], "price": 1.0, "count": "2", "note": null}`
+	const actual = `{"id": 2, "tags": ["b", "a"], "price": 1.01, "count": 2, "note": ""}`
+
+	assert := assert.New(t)
+	ft := &fakeT{}
+	assert.True(AssertJSONEqual(ft, expected, actual,
+		Ignore(`^id$`),
+		IgnoreOrder(`tags`),
+		FloatTolerance(`price`, 0.1),
+		StringNumber(`count`),
+		CoerceNull(`note`),
+	), ft.errors)
+
+	assert.True(AssertJSONEqual(ft, `{"id": "{{uuid}}", "n": "{{number:>0}}"}`, `{"id": "0b5b6a3c-8c59-4a3e-a6a3-6f0e2a7c1d2e", "n": 1}`, Placeholders()), ft.errors)
+	assert.True(AssertJSONEqual(ft, `{"a": [2]}`, `{"a": [1, 2], "b": 1}`, Subset()), ft.errors)
+	assert.False(AssertJSONEqual(ft, `{"a": [2], "c": 1}`, `{"a": [1, 2], "b": 1}`, Subset()))
+
+	s, err := jf.ParseSchema(`{"properties": {"tags": {"uniqueItems": true}}}`)
+	assert.NoError(err)
+	assert.True(AssertJSONEqual(ft, `{"tags": [1, 2]}`, `{"tags": [2, 1]}`, SchemaRules(s)), ft.errors)
+
+	d := jf.NewDiffer().AddIgnore(jf.RuleB, regexp.MustCompile(`extra`))
+	ft = &fakeT{}
+	assert.True(AssertJSONEqual(ft, map[string]int{"a": 1}, `{"a": 1, "extra": 2}`, WithDiffer(d)), ft.errors)
+}
+
+// TestWithDiffer tests options do not modify the Differ passed via WithDiffer
+func TestWithDiffer(t *testing.T) {
+	d := jf.NewDiffer().AddIgnore(jf.RuleB, regexp.MustCompile(`extra`))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ft := &fakeT{}
+			assert.True(t, AssertJSONEqual(ft, `{"id": 1}`, `{"id": 2, "extra": 1}`, WithDiffer(d), Ignore(`^id$`)), ft.errors)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"ignore B extra"}, d.Rules())
+	ft := &fakeT{}
+	assert.False(t, AssertJSONEqual(ft, `{"id": 1}`, `{"id": 2}`, WithDiffer(d)))
+}
+
+func TestRequireJSONEqual(t *testing.T) {
+	ft := &fakeT{}
+	RequireJSONEqual(ft, `{"a": 1}`, `{"a": 1}`)
+	assert.False(t, ft.failed)
+	RequireJSONEqual(ft, `{"a": 1}`, `{"a": 2}`)
+	assert.True(t, ft.failed)
+}
@@ -43,6 +43,7 @@ package jf
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
@@ -62,6 +63,8 @@ import (
    ignoreOrder: ignore order of arrays (nop for other types)
    stringnumber: make "1" equal 1
    customEqual: custom diffing func
+   placeholder: values like "{{uuid}}" are predicates
+   arrayKey: match elements of arrays by a key instead of an index
 */
 type ruleAction int
 
@@ -73,6 +76,8 @@ const (
 	ignoreOrder
 	stringNumber
 	customEqual
+	placeholder
+	arrayKey
 )
 
 // FloatEqualFn is a function comparing two floats
@@ -154,6 +159,13 @@ func (i jsonI) String() string {
 	return fmt.Sprintf("%T %+v", i.i, i.i)
 }
 
+// JoinSelectors returns the selector of a nested value in the format
+// SingleDiff.Selector uses, so "a.b" for key "b" of "a" and "a[1]" for
+// index "[1]"
+func JoinSelectors(mainSelector, selector string) string {
+	return joinSelectors(mainSelector, selector)
+}
+
 func joinSelectors(mainSelector, selector string) string {
 	if mainSelector == "" {
 		return selector
@@ -169,6 +181,8 @@ type rule struct {
 	action          ruleAction
 	floatEqualFunc  FloatEqualFunc
 	customEqualFunc CustomEqualFunc
+	// key is a key of array elements for arrayKey rule
+	key string
 }
 
 type ruleDest int
@@ -236,11 +250,103 @@ func (r *rule) match(selector string) bool {
 	return r.selector.MatchString(selector)
 }
 
+func (a ruleAction) String() string {
+	switch a {
+	case coercenull:
+		return "coerce-null"
+	case ignore:
+		return "ignore"
+	case ignoreIfZero:
+		return "ignore-if-zero"
+	case floatEqual:
+		return "float-equal"
+	case ignoreOrder:
+		return "ignore-order"
+	case stringNumber:
+		return "string-number"
+	case customEqual:
+		return "custom-equal"
+	case placeholder:
+		return "placeholders"
+	case arrayKey:
+		return "array-key"
+	}
+	return fmt.Sprintf("ruleAction(%d)", int(a))
+}
+
+// Rules returns a human readable description of rules in the order they were
+// added, like "ignore B ^id$"
+func (d *Differ) Rules() []string {
+	inB := make(map[*rule]struct{}, len(d.rulesB))
+	for _, r := range d.rulesB {
+		inB[r] = struct{}{}
+	}
+	inA := make(map[*rule]struct{}, len(d.rulesA))
+	ret := make([]string, 0, len(d.rulesA)+len(d.rulesB))
+	for _, r := range d.rulesA {
+		inA[r] = struct{}{}
+		dest := "A"
+		if _, found := inB[r]; found {
+			dest = "AB"
+		}
+		ret = append(ret, fmt.Sprintf("%s %s %s", r.action, dest, r.selector))
+	}
+	for _, r := range d.rulesB {
+		if _, found := inA[r]; found {
+			continue
+		}
+		ret = append(ret, fmt.Sprintf("%s B %s", r.action, r.selector))
+	}
+	return ret
+}
+
+// DiffKind says what kind of difference was found
+type DiffKind int
+
+const (
+	// KindChanged means value is present in both A and B, but differs
+	KindChanged DiffKind = iota
+	// KindRemoved means value is present in A only
+	KindRemoved
+	// KindAdded means value is present in B only
+	KindAdded
+	// KindDuplicateKey means the key is present more than once in the same
+	// object, see DuplicateKeysReport
+	KindDuplicateKey
+	// KindSchema means the value of A or B does not conform to the schema,
+	// see SetSchema
+	KindSchema
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case KindChanged:
+		return "changed"
+	case KindRemoved:
+		return "removed"
+	case KindAdded:
+		return "added"
+	case KindDuplicateKey:
+		return "duplicate-key"
+	case KindSchema:
+		return "schema"
+	}
+	return fmt.Sprintf("DiffKind(%d)", int(k))
+}
+
 // SingleDiff express the difference of one JSON selector
 type SingleDiff struct {
 	selector string
 	valueA   string
 	valueB   string
+	kind     DiffKind
+	posA     Position
+	posB     Position
+	// pointerA and pointerB are JSON Pointers of the values
+	pointerA string
+	pointerB string
+	// message describes schema violation
+	message string
 }
 
 func (d *SingleDiff) Selector() string {
@@ -255,17 +361,100 @@ func (d *SingleDiff) B() string {
 	return d.valueB
 }
 
+func (d *SingleDiff) Kind() DiffKind {
+	return d.kind
+}
+
+// Message returns the description of a schema violation
+func (d *SingleDiff) Message() string {
+	return d.message
+}
+
+// PosA returns the position of a value in jsonA, if known
+func (d *SingleDiff) PosA() Position {
+	return d.posA
+}
+
+// PosB returns the position of a value in jsonB, if known
+func (d *SingleDiff) PosB() Position {
+	return d.posB
+}
+
+// PointerA returns JSON Pointer (RFC 6901) of a value in jsonA. Unlike
+// Selector, it is unambiguous for keys containing dots or brackets.
+func (d *SingleDiff) PointerA() string {
+	return d.pointerA
+}
+
+// PointerB returns JSON Pointer (RFC 6901) of a value in jsonB, it differs
+// from PointerA for array elements paired by AddArrayKey
+func (d *SingleDiff) PointerB() string {
+	return d.pointerB
+}
+
+// rawJSON returns value as json.RawMessage, nil for missing value
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	if !json.Valid([]byte(value)) {
+		b, _ := json.Marshal(value)
+		return b
+	}
+	return json.RawMessage(value)
+}
+
+// MarshalJSON encodes the diff as an object with selector, kind, A and B
+// values as JSON and source positions if known
+func (d SingleDiff) MarshalJSON() ([]byte, error) {
+	type singleDiffJSON struct {
+		Selector string          `json:"selector"`
+		Kind     string          `json:"kind"`
+		A        json.RawMessage `json:"a,omitempty"`
+		B        json.RawMessage `json:"b,omitempty"`
+		PosA     *Position       `json:"posA,omitempty"`
+		PosB     *Position       `json:"posB,omitempty"`
+		Message  string          `json:"message,omitempty"`
+	}
+	v := singleDiffJSON{
+		Selector: d.selector,
+		Kind:     d.kind.String(),
+		A:        rawJSON(d.valueA),
+		B:        rawJSON(d.valueB),
+		Message:  d.message,
+	}
+	if d.posA.IsValid() {
+		v.PosA = &d.posA
+	}
+	if d.posB.IsValid() {
+		v.PosB = &d.posB
+	}
+	return json.Marshal(v)
+}
+
 type DiffList []SingleDiff
 type rules []*rule
 
 // Differ traverse through JSONS and diff each part. It stores actual
 // differences and can apply rules to different parts for comparison
 type Differ struct {
-	diff   DiffList
-	rulesA rules
-	rulesB rules
+	diff          DiffList
+	rulesA        rules
+	rulesB        rules
+	duplicateKeys DuplicateKeysMode
+	positionsA    positions
+	positionsB    positions
+	// maxDiffs stops the diffing after a number of differences, 0 means no limit
+	maxDiffs    int
+	compareMode CompareMode
+	schema      *Schema
+	// placeholders are compiled when the documents are parsed
+	placeholders placeholders
 }
 
+// errMaxDiffs stops the traversal once maxDiffs differences were found
+var errMaxDiffs = errors.New("maximum number of differences reached")
+
 // NewDiffer creates new empty differ with no rules. It can get additional
 // processing rules via AddXYZ methods
 func NewDiffer() *Differ {
@@ -278,15 +467,53 @@ func NewDiffer() *Differ {
 // clone creates an empty Differ with the same set of rules
 func (d *Differ) clone() *Differ {
 	return &Differ{
-		diff:   make(DiffList, 0, 64),
-		rulesA: d.rulesA,
-		rulesB: d.rulesB,
+		diff:          make(DiffList, 0, 64),
+		rulesA:        d.rulesA,
+		rulesB:        d.rulesB,
+		duplicateKeys: d.duplicateKeys,
+		positionsA:    d.positionsA,
+		positionsB:    d.positionsB,
+		maxDiffs:      d.maxDiffs,
+		compareMode:   d.compareMode,
+		schema:        d.schema,
+		placeholders:  d.placeholders,
 	}
 }
 
+// Clone returns a copy of Differ with the same rules and settings. Rules
+// added to the copy do not change the original Differ
+func (d *Differ) Clone() *Differ {
+	d2 := d.clone()
+	d2.rulesA = append(rules(nil), d.rulesA...)
+	d2.rulesB = append(rules(nil), d.rulesB...)
+	return d2
+}
+
+// SetMaxDiffs makes Diff to stop after max differences were found, 0 means
+// no limit
+func (d *Differ) SetMaxDiffs(max int) *Differ {
+	d.maxDiffs = max
+	return d
+}
+
+// full returns true if maxDiffs differences were already found
+func (d *Differ) full() bool {
+	return d.maxDiffs > 0 && len(d.diff) >= d.maxDiffs
+}
+
+// SetDuplicateKeys configures the handling of duplicate keys in objects. By
+// default the last value is used, the same way encoding/json does
+func (d *Differ) SetDuplicateKeys(mode DuplicateKeysMode) *Differ {
+	d.duplicateKeys = mode
+	return d
+}
+
 // lineA adds line with empty B value
-func (d *Differ) lineA(mainSelector, selector string, valueA jsoner) {
-	selector = joinSelectors(mainSelector, selector)
+func (d *Differ) lineA(p path, valueA jsoner) {
+	if d.compareMode == CompareSuperset {
+		return
+	}
+	selector := p.selector
 	shouldIgnoreA, _ := d.shouldIgnore(selector)
 	if shouldIgnoreA {
 		return
@@ -301,11 +528,16 @@ func (d *Differ) lineA(mainSelector, selector string, valueA jsoner) {
 			selector: selector,
 			valueA:   valueA.JSON(),
 			valueB:   "",
+			kind:     KindRemoved,
+			posA:     d.positionsA.lookup(p.pointer),
+			posB:     d.positionsB.lookup(p.pointerB),
+			pointerA: p.pointer,
+			pointerB: p.pointerB,
 		})
 }
 
-func (d *Differ) lineAB(mainSelector, selector string, valueA, valueB jsoner) {
-	selector = joinSelectors(mainSelector, selector)
+func (d *Differ) lineAB(p path, valueA, valueB jsoner) {
+	selector := p.selector
 	shouldIgnoreA, shouldIgnoreB := d.shouldIgnore(selector)
 	if shouldIgnoreA || shouldIgnoreB {
 		return
@@ -320,11 +552,19 @@ func (d *Differ) lineAB(mainSelector, selector string, valueA, valueB jsoner) {
 			selector: selector,
 			valueA:   valueA.JSON(),
 			valueB:   valueB.JSON(),
+			kind:     KindChanged,
+			posA:     d.positionsA.lookup(p.pointer),
+			posB:     d.positionsB.lookup(p.pointerB),
+			pointerA: p.pointer,
+			pointerB: p.pointerB,
 		})
 }
 
-func (d *Differ) lineB(mainSelector, selector string, valueB jsoner) {
-	selector = joinSelectors(mainSelector, selector)
+func (d *Differ) lineB(p path, valueB jsoner) {
+	if d.compareMode == CompareSubset {
+		return
+	}
+	selector := p.selector
 	_, shouldIgnoreB := d.shouldIgnore(selector)
 	if shouldIgnoreB {
 		return
@@ -339,9 +579,46 @@ func (d *Differ) lineB(mainSelector, selector string, valueB jsoner) {
 			selector: selector,
 			valueA:   "",
 			valueB:   valueB.JSON(),
+			kind:     KindAdded,
+			posA:     d.positionsA.lookup(p.pointer),
+			posB:     d.positionsB.lookup(p.pointerB),
+			pointerA: p.pointer,
+			pointerB: p.pointerB,
 		})
 }
 
+// lineDuplicates reports duplicate keys of jsonA and jsonB
+func (d *Differ) lineDuplicates(duplicatesA, duplicatesB []duplicateKey) {
+	for _, dup := range duplicatesA {
+		if shouldIgnoreA, _ := d.shouldIgnore(dup.selector); shouldIgnoreA {
+			continue
+		}
+		d.diff = append(
+			d.diff,
+			SingleDiff{
+				selector: dup.selector,
+				valueA:   jsonI{i: dup.value}.JSON(),
+				kind:     KindDuplicateKey,
+				posA:     dup.pos,
+				pointerA: dup.pointer,
+			})
+	}
+	for _, dup := range duplicatesB {
+		if _, shouldIgnoreB := d.shouldIgnore(dup.selector); shouldIgnoreB {
+			continue
+		}
+		d.diff = append(
+			d.diff,
+			SingleDiff{
+				selector: dup.selector,
+				valueB:   jsonI{i: dup.value}.JSON(),
+				kind:     KindDuplicateKey,
+				posB:     dup.pos,
+				pointerB: dup.pointer,
+			})
+	}
+}
+
 func (d *Differ) matchRule(selector string, action ruleAction) (bool, bool) {
 	matchA := false
 	matchB := false
@@ -438,11 +715,16 @@ func tryAsNumber(valueA *objx.Value) *objx.Value {
 	return objx.MustFromJSON(fmt.Sprintf(`{"a": %s}`, aStr)).Get("a")
 }
 
-func (d *Differ) diffValues(selector string, valueA, valueB *objx.Value) error {
+func (d *Differ) diffValues(p path, valueA, valueB *objx.Value) error {
+	selector := p.selector
+
+	if d.diffPlaceholders(p, valueA, valueB) {
+		return nil
+	}
 
 	if customEqualFunc, has := d.customEqualFunc(selector); has {
 		if !customEqualFunc(selector, valueA, valueB) {
-			d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+			d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		}
 		return nil
 	}
@@ -451,7 +733,7 @@ func (d *Differ) diffValues(selector string, valueA, valueB *objx.Value) error {
 	shouldCoerceA, shouldCoerceB := d.shouldCoerceNull(selector)
 	if (shouldCoerceA && valueA.IsNil()) ||
 		(shouldCoerceB && valueB.IsNil()) {
-		return d.diffValuesCoerced(selector, valueA, valueB, shouldCoerceA, shouldCoerceB)
+		return d.diffValuesCoerced(p, valueA, valueB, shouldCoerceA, shouldCoerceB)
 	}
 
 	// try to parse number as string to number
@@ -468,51 +750,54 @@ func (d *Differ) diffValues(selector string, valueA, valueB *objx.Value) error {
 
 	// 2. types mismatch
 	if reflect.TypeOf(valueA.Data()) != reflect.TypeOf(valueB.Data()) {
-		d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+		d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		return nil
 	}
 
 skipTypeCheck:
 	switch {
+	case valueA.IsNil() && valueB.IsNil():
+		// null equals null, a missing key is reported by diffMap
+		return nil
 	case valueA.IsFloat64() || valueB.IsFloat64():
 		floatA := mustFloat64(valueA)
 		floatB := mustFloat64(valueB)
 		floatEqualFunc := d.floatEqualFunc(selector)
 		if !floatEqualFunc(floatA, floatB) {
-			d.lineAB("", selector, jsonI{valueA, floatEqualFunc}, jsonI{valueB, floatEqualFunc})
+			d.lineAB(p, jsonI{valueA, floatEqualFunc}, jsonI{valueB, floatEqualFunc})
 		}
 	case valueA.IsBool() && valueB.IsBool():
 		intA := valueA.MustBool()
 		intB := valueB.MustBool()
 		if intA != intB {
-			d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+			d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		}
 	case valueA.IsInt():
 		intA := valueA.MustInt()
 		intB := valueB.MustInt()
 		if intA != intB {
-			d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+			d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		}
 	case valueA.IsStr():
 		strA := valueA.MustStr()
 		strB := valueB.MustStr()
 		if strA != strB {
-			d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+			d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		}
 	case valueA.IsObjxMapSlice() && valueB.IsObjxMapSlice():
-		err := d.diffObjxMapSlice(selector, valueA.MustObjxMapSlice(), valueB.MustObjxMapSlice())
+		err := d.diffObjxMapSlice(p, valueA.MustObjxMapSlice(), valueB.MustObjxMapSlice())
 		if err != nil {
 			return err
 		}
 	case valueA.IsInterSlice():
-		err := d.diffInterSlice(selector, valueA, valueB)
+		err := d.diffInterSlice(p, valueA, valueB)
 		if err != nil {
 			return err
 		}
 	case valueA.IsObjxMap() && valueB.IsObjxMap():
 		mA := valueA.MustObjxMap()
 		mB := valueB.MustObjxMap()
-		err := d.diffMap(selector, mA, mB)
+		err := d.diffMap(p, mA, mB)
 		if err != nil {
 			return err
 		}
@@ -525,7 +810,8 @@ skipTypeCheck:
 
 // diffValuesCoerced allows an optional coercion of nulls
 // TODO: join together with diffValues
-func (d *Differ) diffValuesCoerced(selector string, valueA, valueB *objx.Value, coerceA, coerceB bool) error {
+func (d *Differ) diffValuesCoerced(p path, valueA, valueB *objx.Value, coerceA, coerceB bool) error {
+	selector := p.selector
 
 	orNil := func(isType func(v *objx.Value) bool, valueA, valueB *objx.Value) bool {
 		return (isType(valueA) || (coerceA && valueA.IsNil())) &&
@@ -575,7 +861,7 @@ func (d *Differ) diffValuesCoerced(selector string, valueA, valueB *objx.Value,
 		}
 		floatEqualFunc := d.floatEqualFunc(selector)
 		if !floatEqualFunc(floatA, floatB) {
-			d.lineAB("", selector, jsonI{valueA, floatEqualFunc}, jsonI{valueB, floatEqualFunc})
+			d.lineAB(p, jsonI{valueA, floatEqualFunc}, jsonI{valueB, floatEqualFunc})
 		}
 	case isInt(valueA, valueB):
 		var intA, intB int
@@ -590,7 +876,7 @@ func (d *Differ) diffValuesCoerced(selector string, valueA, valueB *objx.Value,
 			intB = valueB.MustInt()
 		}
 		if intA != intB {
-			d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+			d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		}
 	case isStr(valueA, valueB):
 		var strA, strB string
@@ -605,7 +891,7 @@ func (d *Differ) diffValuesCoerced(selector string, valueA, valueB *objx.Value,
 			strB = valueB.MustStr()
 		}
 		if strA != strB {
-			d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+			d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		}
 	case isBool(valueA, valueB):
 		//XXX: isBool check must be after isInt (and probably isStr) otherwise
@@ -622,7 +908,7 @@ func (d *Differ) diffValuesCoerced(selector string, valueA, valueB *objx.Value,
 			intB = valueB.MustBool()
 		}
 		if intA != intB {
-			d.lineAB("", selector, jsonI{i: valueA}, jsonI{i: valueB})
+			d.lineAB(p, jsonI{i: valueA}, jsonI{i: valueB})
 		}
 	case isInterSlice(valueA, valueB):
 		if valueA.IsNil() {
@@ -631,7 +917,7 @@ func (d *Differ) diffValuesCoerced(selector string, valueA, valueB *objx.Value,
 		if valueB.IsNil() {
 			valueB = newValue([]interface{}{})
 		}
-		err := d.diffInterSlice(selector, valueA, valueB)
+		err := d.diffInterSlice(p, valueA, valueB)
 		if err != nil {
 			return err
 		}
@@ -647,7 +933,7 @@ func (d *Differ) diffValuesCoerced(selector string, valueA, valueB *objx.Value,
 		} else {
 			mB = valueB.MustObjxMap()
 		}
-		err := d.diffMap(selector, mA, mB)
+		err := d.diffMap(p, mA, mB)
 		if err != nil {
 			return err
 		}
@@ -664,13 +950,21 @@ func newValue(i interface{}) *objx.Value {
 	return objx.New(m).Get("foo")
 }
 
-func (d *Differ) diffInterSlice(mainSelector string, valueA *objx.Value, valueB *objx.Value) error {
+func (d *Differ) diffInterSlice(p path, valueA *objx.Value, valueB *objx.Value) error {
 	if !valueA.IsInterSlice() || !valueB.IsInterSlice() {
-		return fmt.Errorf("type mismatch for %s, valueA or valueB is not []interface{}, this is programming error", mainSelector)
+		return fmt.Errorf("type mismatch for %s, valueA or valueB is not []interface{}, this is programming error", p.selector)
+	}
+
+	if key, has := d.arrayKey(p.selector); has {
+		return d.diffSliceByKey(p, valueA.MustInterSlice(), valueB.MustInterSlice(), key)
+	}
+
+	if d.compareMode != CompareExact {
+		return d.diffSliceContains(p, valueA.MustInterSlice(), valueB.MustInterSlice())
 	}
 
-	if d.shouldIgnoreOrder(mainSelector) {
-		equal, err := d.diffInterSliceDetectEquals(mainSelector, valueA, valueB)
+	if d.shouldIgnoreOrder(p.selector) {
+		equal, err := d.diffInterSliceDetectEquals(p, valueA, valueB)
 		if err != nil {
 			return err
 		}
@@ -682,14 +976,17 @@ func (d *Differ) diffInterSlice(mainSelector string, valueA *objx.Value, valueB
 	iSliceA := valueA.MustInterSlice()
 	iSliceB := valueB.MustInterSlice()
 	for idx, a := range iSliceA {
+		if d.full() {
+			return errMaxDiffs
+		}
 		if len(iSliceB) <= idx {
-			selector := joinSelectors(mainSelector, fmt.Sprintf("[%d]", idx))
-			floatEqualFunc := d.floatEqualFunc(selector)
-			d.lineA("", selector, jsonI{i: a, floatEqualFunc: floatEqualFunc})
+			item := p.index(idx)
+			floatEqualFunc := d.floatEqualFunc(item.selector)
+			d.lineA(item, jsonI{i: a, floatEqualFunc: floatEqualFunc})
 			continue
 		}
 		b := iSliceB[idx]
-		err := d.diffValues(joinSelectors(mainSelector, fmt.Sprintf("[%d]", idx)), newValue(a), newValue(b))
+		err := d.diffValues(p.index(idx), newValue(a), newValue(b))
 		if err != nil {
 			return err
 		}
@@ -697,10 +994,13 @@ func (d *Differ) diffInterSlice(mainSelector string, valueA *objx.Value, valueB
 
 	if len(iSliceB) > len(iSliceA) {
 		for idx := len(iSliceA); idx != len(iSliceB); idx++ {
+			if d.full() {
+				return errMaxDiffs
+			}
 			b := iSliceB[idx]
-			selector := joinSelectors(mainSelector, fmt.Sprintf("[%d]", idx))
-			floatEqualFunc := d.floatEqualFunc(selector)
-			d.lineB("", selector, jsonI{i: b, floatEqualFunc: floatEqualFunc})
+			item := p.index(idx)
+			floatEqualFunc := d.floatEqualFunc(item.selector)
+			d.lineB(item, jsonI{i: b, floatEqualFunc: floatEqualFunc})
 		}
 	}
 	return nil
@@ -721,13 +1021,13 @@ func (s intSet) Has(v int) bool {
 	return has
 }
 
-func (d *Differ) diffInterSliceDetectEquals(mainSelector string, valueA *objx.Value, valueB *objx.Value) (bool, error) {
+func (d *Differ) diffInterSliceDetectEquals(p path, valueA *objx.Value, valueB *objx.Value) (bool, error) {
 
 	idxEqualA := newIntSet()
 	idxEqualB := newIntSet()
 
 	if !valueA.IsInterSlice() || !valueB.IsInterSlice() {
-		return false, fmt.Errorf("type mismatch for %s, valueA or valueB is not []interface{}, this is programming error", mainSelector)
+		return false, fmt.Errorf("type mismatch for %s, valueA or valueB is not []interface{}, this is programming error", p.selector)
 	}
 
 	iSliceA := valueA.MustInterSlice()
@@ -736,6 +1036,7 @@ func (d *Differ) diffInterSliceDetectEquals(mainSelector string, valueA *objx.Va
 	// clone Differ with empty diff - this help code reuse and won't mess with
 	// the main diff
 	other := d.clone()
+	other.maxDiffs = 1
 	for idxA, a := range iSliceA {
 		for idxB, b := range iSliceB {
 			if idxEqualB.Has(idxB) {
@@ -743,8 +1044,8 @@ func (d *Differ) diffInterSliceDetectEquals(mainSelector string, valueA *objx.Va
 			}
 
 			other.diff = make(DiffList, 0, 64)
-			err := other.diffValues(joinSelectors(mainSelector, fmt.Sprintf("[%d]", idxA)), newValue(a), newValue(b))
-			if err != nil {
+			err := other.diffValues(p.index(idxA), newValue(a), newValue(b))
+			if err != nil && err != errMaxDiffs {
 				return false, err
 			}
 			if len(other.diff) == 0 {
@@ -759,17 +1060,28 @@ func (d *Differ) diffInterSliceDetectEquals(mainSelector string, valueA *objx.Va
 	return len(idxEqualA) == len(iSliceA) && len(idxEqualB) == len(iSliceB), nil
 }
 
-func (d *Differ) diffObjxMapSlice(mainSelector string, sliceA, sliceB []objx.Map) error {
+func (d *Differ) diffObjxMapSlice(p path, sliceA, sliceB []objx.Map) error {
+
+	if key, has := d.arrayKey(p.selector); has {
+		return d.diffSliceByKey(p, objxMapSlice(sliceA), objxMapSlice(sliceB), key)
+	}
+
+	if d.compareMode != CompareExact {
+		return d.diffSliceContains(p, objxMapSlice(sliceA), objxMapSlice(sliceB))
+	}
 
 	for idx, a := range sliceA {
+		if d.full() {
+			return errMaxDiffs
+		}
 		if len(sliceB) <= idx {
-			selector := joinSelectors(mainSelector, fmt.Sprintf("[%d]", idx))
-			floatEqualFunc := d.floatEqualFunc(selector)
-			d.lineA("", selector, jsonI{i: a, floatEqualFunc: floatEqualFunc})
+			item := p.index(idx)
+			floatEqualFunc := d.floatEqualFunc(item.selector)
+			d.lineA(item, jsonI{i: a, floatEqualFunc: floatEqualFunc})
 			continue
 		}
 		b := sliceB[idx]
-		err := d.diffMap(joinSelectors(mainSelector, fmt.Sprintf("[%d]", idx)), a, b)
+		err := d.diffMap(p.index(idx), a, b)
 		if err != nil {
 			return err
 		}
@@ -777,10 +1089,13 @@ func (d *Differ) diffObjxMapSlice(mainSelector string, sliceA, sliceB []objx.Map
 
 	if len(sliceB) > len(sliceA) {
 		for idx := len(sliceA); idx != len(sliceB); idx++ {
+			if d.full() {
+				return errMaxDiffs
+			}
 			b := sliceB[idx]
-			selector := joinSelectors(mainSelector, fmt.Sprintf("[%d]", idx))
-			floatEqualFunc := d.floatEqualFunc(selector)
-			d.lineB("", selector, jsonI{i: b, floatEqualFunc: floatEqualFunc})
+			item := p.index(idx)
+			floatEqualFunc := d.floatEqualFunc(item.selector)
+			d.lineB(item, jsonI{i: b, floatEqualFunc: floatEqualFunc})
 		}
 	}
 	return nil
@@ -797,34 +1112,40 @@ func sortedKeys(m map[string]interface{}) []string {
 	return keys
 }
 
-func (d *Differ) diffMap(mainSelector string, objA objx.Map, objB objx.Map) error {
+func (d *Differ) diffMap(p path, objA objx.Map, objB objx.Map) error {
 
 	visitedKeysA := make(map[string]struct{})
 	for _, keyA := range sortedKeys(objA) {
+		if d.full() {
+			return errMaxDiffs
+		}
 		visitedKeysA[keyA] = struct{}{}
-		valueA := objA.Get(keyA)
-		// 1. objB missing data
-		if !objB.Has(keyA) {
-			selector := joinSelectors(mainSelector, keyA)
-			floatEqualFunc := d.floatEqualFunc(selector)
-			d.lineA("", selector, jsonI{i: valueA, floatEqualFunc: floatEqualFunc})
+		valueA := newValue(objA[keyA])
+		// 1. objB missing data, note objx.Map.Has returns false for null values
+		if _, has := objB[keyA]; !has {
+			item := p.key(keyA)
+			floatEqualFunc := d.floatEqualFunc(item.selector)
+			d.lineA(item, jsonI{i: valueA, floatEqualFunc: floatEqualFunc})
 			continue
 		}
-		valueB := objB.Get(keyA)
+		valueB := newValue(objB[keyA])
 
-		err := d.diffValues(joinSelectors(mainSelector, keyA), valueA, valueB)
+		err := d.diffValues(p.key(keyA), valueA, valueB)
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, keyB := range sortedKeys(objB) {
+		if d.full() {
+			return errMaxDiffs
+		}
 		if _, found := visitedKeysA[keyB]; found {
 			continue
 		}
-		selector := joinSelectors(mainSelector, keyB)
-		floatEqualFunc := d.floatEqualFunc(selector)
-		d.lineB("", selector, jsonI{i: objB.Get(keyB), floatEqualFunc: floatEqualFunc})
+		item := p.key(keyB)
+		floatEqualFunc := d.floatEqualFunc(item.selector)
+		d.lineB(item, jsonI{i: newValue(objB[keyB]), floatEqualFunc: floatEqualFunc})
 	}
 
 	return nil
@@ -835,21 +1156,43 @@ func (d *Differ) diffMap(mainSelector string, objA objx.Map, objB objx.Map) erro
 // strings
 func (d *Differ) Diff(jsonA, jsonB string) (DiffList, error) {
 
-	objA, err := objx.FromJSON(jsonA)
+	docA, err := parseDocument(jsonA)
 	if err != nil {
-		return []SingleDiff{}, err
+		return []SingleDiff{}, fmt.Errorf("jsonA: %w", err)
 	}
-	objB, err := objx.FromJSON(jsonB)
+	docB, err := parseDocument(jsonB)
 	if err != nil {
+		return []SingleDiff{}, fmt.Errorf("jsonB: %w", err)
+	}
+
+	d2 := d.clone()
+	d2.positionsA = docA.positions
+	d2.positionsB = docB.positions
+	if err := d2.initPlaceholders(docA.root, docB.root); err != nil {
 		return []SingleDiff{}, err
 	}
+	switch d.duplicateKeys {
+	case DuplicateKeysError:
+		if len(docA.duplicates) > 0 {
+			dup := docA.duplicates[0]
+			return []SingleDiff{}, fmt.Errorf("jsonA: %w", &DuplicateKeyError{Selector: dup.selector, Pos: dup.pos})
+		}
+		if len(docB.duplicates) > 0 {
+			dup := docB.duplicates[0]
+			return []SingleDiff{}, fmt.Errorf("jsonB: %w", &DuplicateKeyError{Selector: dup.selector, Pos: dup.pos})
+		}
+	case DuplicateKeysReport:
+		d2.lineDuplicates(docA.duplicates, docB.duplicates)
+	}
+	if d.schema != nil {
+		d2.lineSchema(d.schema.validate(docA.root), true)
+		d2.lineSchema(d.schema.validate(docB.root), false)
+	}
 
-	d2 := Differ{
-		diff:   make(DiffList, 0, 64),
-		rulesA: d.rulesA,
-		rulesB: d.rulesB,
+	err = d2.diffMap(path{}, docA.root, docB.root)
+	if d2.full() {
+		return d2.diff[:d2.maxDiffs], nil
 	}
-	err = d2.diffMap("", objA, objB)
 	if err != nil {
 		return d2.diff, err
 	}
@@ -857,6 +1200,21 @@ func (d *Differ) Diff(jsonA, jsonB string) (DiffList, error) {
 
 }
 
+// Equal returns true if jsonA and jsonB has no differences. Unlike Diff it
+// stops on the first one
+func (d *Differ) Equal(jsonA, jsonB string) (bool, error) {
+	diff, err := d.clone().SetMaxDiffs(1).Diff(jsonA, jsonB)
+	if err != nil {
+		return false, err
+	}
+	return len(diff) == 0, nil
+}
+
+// Equal is a shortcut for NewDiffer().Equal
+func Equal(jsonA, jsonB string) (bool, error) {
+	return NewDiffer().Equal(jsonA, jsonB)
+}
+
 // Diff is a shortcut for NewDiffer().Diff, exact diffing without any filters
 func Diff(jsonA, jsonB string) (DiffList, error) {
 	return NewDiffer().Diff(jsonA, jsonB)
@@ -1,6 +1,7 @@
 package jf
 
 import (
+	"encoding/json"
 	"math"
 	"regexp"
 	"testing"
@@ -17,6 +18,14 @@ func re(t *testing.T, s string) *regexp.Regexp {
 	return re
 }
 
+// assertLine checks the selector and values of a single diff line
+func assertLine(t *testing.T, line SingleDiff, selector, a, b string) {
+	t.Helper()
+	assert.Equal(t, selector, line.Selector(), "selector")
+	assert.Equal(t, a, line.A(), "value A of %s", selector)
+	assert.Equal(t, b, line.B(), "value B of %s", selector)
+}
+
 // TestSimpleMap tests diff handling of primitive types (int/string) and slices
 func TestSimpleMap(t *testing.T) {
 
@@ -43,12 +52,12 @@ func TestSimpleMap(t *testing.T) {
 	assert.NoError(err)
 	assert.Len(lines, 6)
 
-	assert.Equal(SingleDiff{"bool", "true", "false"}, lines[0])
-	assert.Equal(SingleDiff{"float", "11.1", "11.11"}, lines[1])
-	assert.Equal(SingleDiff{"ints[2]", "1", "99"}, lines[2])
-	assert.Equal(SingleDiff{"number", "42", "43"}, lines[3])
-	assert.Equal(SingleDiff{"string", `"hello"`, `"hellp"`}, lines[4])
-	assert.Equal(SingleDiff{"strings[1]", `"world"`, `"worle"`}, lines[5])
+	assertLine(t, lines[0], "bool", "true", "false")
+	assertLine(t, lines[1], "float", "11.1", "11.11")
+	assertLine(t, lines[2], "ints[2]", "1", "99")
+	assertLine(t, lines[3], "number", "42", "43")
+	assertLine(t, lines[4], "string", `"hello"`, `"hellp"`)
+	assertLine(t, lines[5], "strings[1]", `"world"`, `"worle"`)
 }
 
 // TestDifferentKeys tests the case that in MSI there are different keys
@@ -66,8 +75,8 @@ func TestDifferentKeys(t *testing.T) {
 	assert.NoError(err)
 	assert.Len(lines, 2)
 
-	assert.Equal(SingleDiff{"numberA", "42", ""}, lines[0])
-	assert.Equal(SingleDiff{"numberB", "", "42"}, lines[1])
+	assertLine(t, lines[0], "numberA", "42", "")
+	assertLine(t, lines[1], "numberB", "", "42")
 }
 
 // TestDifferentArrays sizes
@@ -89,11 +98,11 @@ func TestDifferentArrays(t *testing.T) {
 	assert.NoError(err)
 	assert.Len(lines, 5)
 
-	assert.Equal(SingleDiff{"bigger[1]", "", "20"}, lines[0])
-	assert.Equal(SingleDiff{"bigger[2]", "", "30"}, lines[1])
-	assert.Equal(SingleDiff{"smaller[1]", "2", ""}, lines[2])
-	assert.Equal(SingleDiff{"weird[0]", "10", "30"}, lines[3])
-	assert.Equal(SingleDiff{"weird[1]", "20", "40"}, lines[4])
+	assertLine(t, lines[0], "bigger[1]", "", "20")
+	assertLine(t, lines[1], "bigger[2]", "", "30")
+	assertLine(t, lines[2], "smaller[1]", "2", "")
+	assertLine(t, lines[3], "weird[0]", "10", "30")
+	assertLine(t, lines[4], "weird[1]", "20", "40")
 }
 
 func TestMapInMap(t *testing.T) {
@@ -114,7 +123,7 @@ func TestMapInMap(t *testing.T) {
 	lines, err := Diff(jsonA, jsonB)
 	assert.NoError(err)
 	assert.Len(lines, 1)
-	assert.Equal(SingleDiff{"key.name", `"joe"`, `"Joe"`}, lines[0])
+	assertLine(t, lines[0], "key.name", `"joe"`, `"Joe"`)
 }
 
 func TestMapInMapInMap(t *testing.T) {
@@ -139,7 +148,7 @@ func TestMapInMapInMap(t *testing.T) {
 	lines, err := Diff(jsonA, jsonB)
 	assert.NoError(err)
 	assert.Len(lines, 1)
-	assert.Equal(SingleDiff{"key.subkey.name", `"joe"`, `"Joe"`}, lines[0])
+	assertLine(t, lines[0], "key.subkey.name", `"joe"`, `"Joe"`)
 }
 
 func TestMapSlice(t *testing.T) {
@@ -162,8 +171,8 @@ func TestMapSlice(t *testing.T) {
 	lines, err := Diff(jsonA, jsonB)
 	assert.NoError(err)
 	assert.Len(lines, 2)
-	assert.Equal(SingleDiff{"data[0].name", `"one"`, `"One"`}, lines[0])
-	assert.Equal(SingleDiff{"data[1].name", `"two"`, `"Two"`}, lines[1])
+	assertLine(t, lines[0], "data[0].name", `"one"`, `"One"`)
+	assertLine(t, lines[1], "data[1].name", `"two"`, `"Two"`)
 }
 
 func TestNil(t *testing.T) {
@@ -178,7 +187,35 @@ func TestNil(t *testing.T) {
 	lines, err := Diff(jsonA, jsonB)
 	assert.NoError(err)
 	assert.Len(lines, 1)
-	assert.Equal(SingleDiff{"key", "null", "42"}, lines[0])
+	assertLine(t, lines[0], "key", "null", "42")
+}
+
+// TestNilNil tests null is equal to null and not to a missing key
+func TestNilNil(t *testing.T) {
+	const jsonA = `{
+        "key": null,
+        "missing": null
+    }`
+	const jsonB = `{
+        "key": null
+    }`
+
+	assert := assert.New(t)
+	lines, err := Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "missing", "null", "")
+
+	// the same from the B side
+	lines, err = Diff(jsonB, jsonA)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "missing", "", "null")
+
+	// nulls inside of arrays and nested objects
+	lines, err = Diff(`{"list": [null, 1], "obj": {"a": null}}`, `{"list": [null, 1], "obj": {"a": null}}`)
+	assert.NoError(err)
+	assert.Len(lines, 0)
 }
 
 // TestCoerceNull tests null coercion of jsonA only, jsonB only and both
@@ -201,7 +238,7 @@ func TestCoerceNull(t *testing.T) {
 	lines, err = NewDiffer().AddCoerceNull(RuleB, re(t, `.*`)).Diff(jsonA, jsonB)
 	assert.NoError(err)
 	assert.Len(lines, 1)
-	assert.Equal(SingleDiff{"key", "null", "0"}, lines[0])
+	assertLine(t, lines[0], "key", "null", "0")
 	// 3. coercion of A/B, return 0 lines
 	lines, err = NewDiffer().AddCoerceNull(RuleAB, re(t, `.*`)).Diff(jsonA, jsonB)
 	assert.NoError(err)
@@ -227,7 +264,7 @@ func TestCoerceNullMatch(t *testing.T) {
 	lines, err := NewDiffer().AddCoerceNull(RuleA, re(t, `key\.subkey1`)).Diff(jsonA, jsonB)
 	assert.NoError(err)
 	assert.Len(lines, 1)
-	assert.Equal(SingleDiff{"key.subkey2", "null", "0"}, lines[0])
+	assertLine(t, lines[0], "key.subkey2", "null", "0")
 }
 
 func TestIgnore(t *testing.T) {
@@ -243,7 +280,7 @@ func TestIgnore(t *testing.T) {
 	lines, err := NewDiffer().AddIgnore(RuleA, re(t, `additional`)).Diff(jsonA, jsonB)
 	assert.NoError(err)
 	assert.Len(lines, 1)
-	assert.Equal(SingleDiff{"additional", "", "42"}, lines[0])
+	assertLine(t, lines[0], "additional", "", "42")
 
 	lines, err = NewDiffer().AddIgnore(RuleB, re(t, `additional`)).Diff(jsonA, jsonB)
 	assert.NoError(err)
@@ -407,3 +444,105 @@ func TestCustomEqual(t *testing.T) {
 	assert.NoError(err)
 	assert.Len(lines, 0)
 }
+
+func TestSingleDiffMarshalJSON(t *testing.T) {
+	const jsonA = `{"key": {"a": 1}, "removed": "x\ty"}`
+	const jsonB = `{"key": [1], "added": null}`
+
+	assert := assert.New(t)
+	lines, err := Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 3)
+
+	b, err := json.Marshal(lines)
+	assert.NoError(err)
+	assert.JSONEq(`[
+        {"selector": "key", "kind": "changed", "a": {"a": 1}, "b": [1],
+         "posA": {"offset": 8, "line": 1, "column": 9},
+         "posB": {"offset": 8, "line": 1, "column": 9}},
+        {"selector": "removed", "kind": "removed", "a": "x\ty",
+         "posA": {"offset": 29, "line": 1, "column": 30},
+         "posB": {"offset": 0, "line": 1, "column": 1}},
+        {"selector": "added", "kind": "added", "b": null,
+         "posA": {"offset": 0, "line": 1, "column": 1},
+         "posB": {"offset": 22, "line": 1, "column": 23}}
+    ]`, string(b))
+
+	// no positions for Go values
+	lines, err = DiffGo(map[string]int{"a": 1}, map[string]int{"a": 2})
+	assert.NoError(err)
+	b, err = json.Marshal(lines[0])
+	assert.NoError(err)
+	assert.JSONEq(`{"selector": "a", "kind": "changed", "a": 1, "b": 2}`, string(b))
+}
+
+func TestRules(t *testing.T) {
+	d := NewDiffer().
+		AddIgnore(RuleB, re(t, `^id$`)).
+		AddCoerceNull(RuleA, re(t, `.*`)).
+		AddIgnoreOrder(re(t, `list`))
+	assert.Equal(t, []string{
+		"coerce-null A .*",
+		"ignore-order AB list",
+		"ignore B ^id$",
+	}, d.Rules())
+	assert.Len(t, NewDiffer().Rules(), 0)
+}
+
+func TestClone(t *testing.T) {
+	d := NewDiffer().
+		AddIgnore(RuleA, re(t, `a`)).
+		AddIgnore(RuleA, re(t, `b`)).
+		AddIgnore(RuleA, re(t, `c`))
+	x := d.Clone().AddIgnore(RuleA, re(t, `x`))
+	y := d.Clone().AddIgnore(RuleAB, re(t, `y`))
+
+	assert.Equal(t, []string{"ignore A a", "ignore A b", "ignore A c"}, d.Rules())
+	assert.Equal(t, []string{"ignore A a", "ignore A b", "ignore A c", "ignore A x"}, x.Rules())
+	assert.Equal(t, []string{"ignore A a", "ignore A b", "ignore A c", "ignore AB y"}, y.Rules())
+}
+
+func TestEqual(t *testing.T) {
+	assert := assert.New(t)
+
+	equal, err := Equal(`{"a": [1, 2], "b": {"c": null}}`, `{"b": {"c": null}, "a": [1, 2]}`)
+	assert.NoError(err)
+	assert.True(equal)
+
+	equal, err = Equal(`{"a": 1}`, `{"a": 2}`)
+	assert.NoError(err)
+	assert.False(equal)
+
+	equal, err = NewDiffer().
+		AddIgnore(RuleAB, re(t, `^id$`)).
+		AddIgnoreOrder(re(t, `list`)).
+		Equal(`{"id": 1, "list": [1, 2, 3]}`, `{"id": 2, "list": [3, 2, 1]}`)
+	assert.NoError(err)
+	assert.True(equal)
+
+	_, err = Equal(`{"a": 1`, `{}`)
+	assert.Error(err)
+}
+
+func TestMaxDiffs(t *testing.T) {
+	const jsonA = `{"a": 1, "b": [1, 2, 3], "c": {"d": 1, "e": 1}}`
+	const jsonB = `{"a": 2, "b": [4, 5, 6], "c": {"d": 2, "e": 2}}`
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().SetMaxDiffs(3).Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 3)
+	assertLine(t, lines[0], "a", "1", "2")
+	assertLine(t, lines[2], "b[1]", "2", "5")
+
+	lines, err = NewDiffer().SetMaxDiffs(100).Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 6)
+}
+
+func TestJoinSelectors(t *testing.T) {
+	assert.Equal(t, "a", JoinSelectors("", "a"))
+	assert.Equal(t, "a.b", JoinSelectors("a", "b"))
+	assert.Equal(t, "a[1]", JoinSelectors("a", "[1]"))
+	assert.Equal(t, "[1]", JoinSelectors("", "[1]"))
+}
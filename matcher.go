@@ -0,0 +1,134 @@
+package jf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// JSONMatcher matches JSON payloads with the rules of Differ. It implements
+// gomock.Matcher interface and Matches can be passed to testify
+// mock.MatchedBy.
+type JSONMatcher struct {
+	d        *Differ
+	expected string
+
+	mu sync.Mutex
+	// last is a report of the last mismatch for the failure message
+	last string
+}
+
+// Rule configures a Differ, like
+//
+//	func(d *jf.Differ) { d.AddIgnore(jf.RuleAB, regexp.MustCompile(`^time$`)) }
+type Rule func(d *Differ)
+
+// Matcher returns JSONMatcher comparing values with expected JSON using the
+// rules of d and the additional rules. The rules are applied to a copy of d.
+func (d *Differ) Matcher(expected string, with ...Rule) *JSONMatcher {
+	d2 := d.Clone()
+	for _, rule := range with {
+		rule(d2)
+	}
+	return &JSONMatcher{d: d2, expected: expected}
+}
+
+// Matcher is a shortcut for NewDiffer().Matcher
+func Matcher(expected string, with ...Rule) *JSONMatcher {
+	return NewDiffer().Matcher(expected, with...)
+}
+
+// ToJSON converts string, []byte and json.RawMessage to string, other values
+// are encoded by encoding/json
+func ToJSON(x interface{}) (string, error) {
+	switch v := x.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Report formats differences as a multi-line table of selectors, expected
+// (A) and actual (B) values. Schema violations show the message in place of
+// the missing value.
+func (l DiffList) Report() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d differences\n", len(l))
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\tselector\texpected\tactual\n")
+	for _, p := range l {
+		expected, actual := p.valueA, p.valueB
+		switch p.kind {
+		case KindRemoved:
+			actual = "(missing)"
+		case KindAdded:
+			expected = "(missing)"
+		case KindDuplicateKey:
+			if expected == "" {
+				expected = "(duplicate key)"
+			} else {
+				actual = "(duplicate key)"
+			}
+		case KindSchema:
+			// the value is on the side of the invalid input
+			if actual == "" {
+				actual = "(schema: " + p.message + ")"
+			} else {
+				expected = "(schema: " + p.message + ")"
+			}
+		}
+		fmt.Fprintf(tw, "\t%s\t%s\t%s\n", p.selector, expected, actual)
+	}
+	tw.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Matches returns true if x is JSON equal to the expected one. Strings,
+// []byte and json.RawMessage are parsed, other values are encoded by
+// encoding/json first.
+func (m *JSONMatcher) Matches(x interface{}) bool {
+	last := ""
+	defer func() {
+		m.mu.Lock()
+		m.last = last
+		m.mu.Unlock()
+	}()
+
+	actual, err := ToJSON(x)
+	if err != nil {
+		last = err.Error()
+		return false
+	}
+	diff, err := m.d.Diff(m.expected, actual)
+	if err != nil {
+		last = err.Error()
+		return false
+	}
+	if len(diff) > 0 {
+		last = diff.Report()
+		return false
+	}
+	return true
+}
+
+// String describes the matcher and the differences found by the last call
+// of Matches
+func (m *JSONMatcher) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == "" {
+		return fmt.Sprintf("is JSON equal to %s", m.expected)
+	}
+	return fmt.Sprintf("is JSON equal to %s, last value has %s", m.expected, m.last)
+}
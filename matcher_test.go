@@ -0,0 +1,100 @@
+package jf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// gomockMatcher is a copy of gomock.Matcher interface
+type gomockMatcher interface {
+	Matches(x interface{}) bool
+	String() string
+}
+
+var _ gomockMatcher = &JSONMatcher{}
+
+func TestMatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	m := NewDiffer().AddIgnore(RuleAB, re(t, `^created$`)).Matcher(`{"id": 1, "created": "12:00"}`)
+	assert.Equal(`is JSON equal to {"id": 1, "created": "12:00"}`, m.String())
+	assert.True(m.Matches(`{"created": "13:00", "id": 1}`))
+	assert.True(m.Matches([]byte(`{"id": 1}`)))
+	assert.True(m.Matches(struct {
+		ID int `json:"id"`
+	}{1}))
+
+	assert.False(m.Matches(map[string]interface{}{"id": 2, "name": "x"}))
+	assert.Equal(`is JSON equal to {"id": 1, "created": "12:00"}, last value has 2 differences
+  selector  expected   actual
+  id        1          2
+  name      (missing)  "x"`, m.String())
+
+	assert.False(m.Matches(`[]`))
+	assert.Contains(m.String(), "last value has jsonB: ")
+
+	assert.False(m.Matches(make(chan int)))
+	assert.Contains(m.String(), "unsupported type")
+
+	assert.True(Matcher(`{}`).Matches(`{}`))
+}
+
+func TestMatcherRules(t *testing.T) {
+	assert := assert.New(t)
+	ignoreTime := func(d *Differ) { d.AddIgnore(RuleAB, re(t, `^time$`)) }
+	ignoreOrder := func(d *Differ) { d.AddIgnoreOrder(re(t, `^tags$`)) }
+
+	m := Matcher(`{"msg": "hello", "tags": ["a", "b"]}`, ignoreTime, ignoreOrder)
+	assert.True(m.Matches(`{"msg": "hello", "tags": ["b", "a"], "time": "12:00"}`))
+	assert.False(m.Matches(`{"msg": "bye", "tags": ["b", "a"]}`))
+
+	// rules are added to a copy
+	d := NewDiffer()
+	assert.True(d.Matcher(`{"msg": "hello"}`, ignoreTime).Matches(`{"msg": "hello", "time": "12:00"}`))
+	assert.Len(d.Rules(), 0)
+}
+
+// TestDiffListReport tests the table of differences
+func TestDiffListReport(t *testing.T) {
+	diff, err := NewDiffer().SetDuplicateKeys(DuplicateKeysReport).Diff(
+		`{"a": 1, "b": "x", "c": true, "c": false}`,
+		`{"a": 2, "c": false, "d": null}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `4 differences
+  selector  expected   actual
+  c         false      (duplicate key)
+  a         1          2
+  b         "x"        (missing)
+  d         (missing)  null`, diff.Report())
+
+	s, err := ParseSchema(`{"properties": {"a": {"type": "integer"}}, "required": ["b"]}`)
+	assert.NoError(t, err)
+	diff, err = NewDiffer().SetSchema(s).Diff(`{"a": "x", "b": 1}`, `{"a": 1}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `4 differences
+  selector  expected  actual
+  a         "x"       (schema: must be integer)
+  b                   (schema: is required)
+  a         "x"       1
+  b         1         (missing)`, diff.Report())
+}
+
+type sender struct {
+	mock.Mock
+}
+
+func (s *sender) Send(payload string) {
+	s.Called(payload)
+}
+
+func TestMatcherTestify(t *testing.T) {
+	s := &sender{}
+	m := NewDiffer().AddIgnore(RuleAB, re(t, `^time$`)).Matcher(`{"msg": "hello"}`)
+	s.On("Send", mock.MatchedBy(m.Matches)).Return()
+
+	s.Send(fmt.Sprintf(`{"msg": "hello", "time": %q}`, "12:00"))
+	s.AssertExpectations(t)
+}
@@ -0,0 +1,421 @@
+package jf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// Position is a location in JSON source
+type Position struct {
+	Offset int `json:"offset"` // byte offset, starting at 0
+	Line   int `json:"line"`   // line number, starting at 1
+	Column int `json:"column"` // column number in bytes, starting at 1
+}
+
+// IsValid returns true if position is known
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// ParseError is returned for a malformed JSON input
+type ParseError struct {
+	Pos Position
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// DuplicateKeyError is returned if object contains the same key twice and
+// Differ is configured to fail on duplicate keys
+type DuplicateKeyError struct {
+	Selector string
+	Pos      Position
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("line %d column %d: duplicate key %q", e.Pos.Line, e.Pos.Column, e.Selector)
+}
+
+// DuplicateKeysMode says how Differ treats objects with duplicate keys
+type DuplicateKeysMode int
+
+const (
+	// DuplicateKeysIgnore keeps the last value, the same as encoding/json does
+	DuplicateKeysIgnore DuplicateKeysMode = iota
+	// DuplicateKeysError makes Diff to fail with *DuplicateKeyError
+	DuplicateKeysError
+	// DuplicateKeysReport reports each duplicate key as a diff of
+	// KindDuplicateKey. The last value is used for comparison.
+	DuplicateKeysReport
+)
+
+// path is a location of a value. Selector is used by rules and in reports,
+// pointer is a JSON Pointer of the same value. Unlike selector it is
+// unambiguous for keys containing "." or "[", so positions are keyed by it.
+// pointerB differs from pointer if array elements at different indexes are
+// paired, like by AddArrayKey.
+type path struct {
+	selector string
+	pointer  string
+	pointerB string
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// key returns the path of an object member
+func (p path) key(key string) path {
+	escaped := "/" + pointerEscaper.Replace(key)
+	return path{
+		selector: joinSelectors(p.selector, key),
+		pointer:  p.pointer + escaped,
+		pointerB: p.pointerB + escaped,
+	}
+}
+
+// index returns the path of an array element
+func (p path) index(idx int) path {
+	return p.indexAB(idx, idx)
+}
+
+// indexAB returns the path of array elements paired from index idxA of A and
+// idxB of B. Selector uses the index of A.
+func (p path) indexAB(idxA, idxB int) path {
+	return path{
+		selector: joinSelectors(p.selector, fmt.Sprintf("[%d]", idxA)),
+		pointer:  p.pointer + "/" + strconv.Itoa(idxA),
+		pointerB: p.pointerB + "/" + strconv.Itoa(idxB),
+	}
+}
+
+// positions maps JSON Pointers to the positions of values in JSON source
+type positions map[string]Position
+
+// lookup returns the position of a value. If pointer is not known, which
+// happens for values missing in one of inputs, the position of the nearest
+// parent is returned.
+func (p positions) lookup(pointer string) Position {
+	if p == nil {
+		return Position{}
+	}
+	for {
+		if pos, found := p[pointer]; found {
+			return pos
+		}
+		if pointer == "" {
+			return Position{}
+		}
+		pointer = parentPointer(pointer)
+	}
+}
+
+// parentPointer returns the JSON Pointer of a parent value, so "/a/b/1" for
+// "/a/b/1/c"
+func parentPointer(pointer string) string {
+	if idx := strings.LastIndex(pointer, "/"); idx != -1 {
+		return pointer[:idx]
+	}
+	return ""
+}
+
+// duplicateKey is a key found more than once in the same object
+type duplicateKey struct {
+	selector string
+	pointer  string
+	value    interface{}
+	pos      Position
+}
+
+// document is a parsed JSON input
+type document struct {
+	root       objx.Map
+	duplicates []duplicateKey
+	positions  positions
+}
+
+// maxNestingDepth limits nested objects and arrays the same way as
+// encoding/json, so deep input does not exhaust the stack
+const maxNestingDepth = 10000
+
+// parser is a simple recursive descent JSON parser. Unlike encoding/json it
+// knows where each value is and detects duplicate keys
+type parser struct {
+	data       []byte
+	off        int
+	line       int
+	lineStart  int
+	depth      int
+	duplicates []duplicateKey
+	positions  positions
+}
+
+// parseDocument parses JSON input, which must be an object. The result uses
+// the same types as objx.FromJSON
+func parseDocument(js string) (*document, error) {
+	p := &parser{data: []byte(js), line: 1, positions: make(positions)}
+	p.skipWS()
+	if p.peek() != '{' {
+		return nil, p.errorf("top level value must be an object")
+	}
+	v, err := p.parseValue(path{})
+	if err != nil {
+		return nil, err
+	}
+	p.skipWS()
+	if p.off != len(p.data) {
+		return nil, p.errorf("invalid character %q after top-level value", p.data[p.off])
+	}
+	return &document{
+		root:       v.(objx.Map),
+		duplicates: p.duplicates,
+		positions:  p.positions,
+	}, nil
+}
+
+func (p *parser) pos() Position {
+	return Position{Offset: p.off, Line: p.line, Column: p.off - p.lineStart + 1}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &ParseError{Pos: p.pos(), Msg: fmt.Sprintf(format, args...)}
+}
+
+// peek returns current byte or zero at the end of input
+func (p *parser) peek() byte {
+	if p.off >= len(p.data) {
+		return 0
+	}
+	return p.data[p.off]
+}
+
+func (p *parser) skipWS() {
+	for p.off < len(p.data) {
+		switch p.data[p.off] {
+		case '\n':
+			p.line++
+			p.lineStart = p.off + 1
+		case ' ', '\t', '\r':
+		default:
+			return
+		}
+		p.off++
+	}
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipWS()
+	if p.peek() != c {
+		return p.unexpected()
+	}
+	p.off++
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	if p.off >= len(p.data) {
+		return p.errorf("unexpected end of JSON input")
+	}
+	return p.errorf("invalid character %q", p.data[p.off])
+}
+
+func (p *parser) parseValue(at path) (interface{}, error) {
+	p.skipWS()
+	p.positions[at.pointer] = p.pos()
+	switch c := p.peek(); {
+	case c == '{' || c == '[':
+		if p.depth >= maxNestingDepth {
+			return nil, p.errorf("exceeded max depth %d", maxNestingDepth)
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		if c == '{' {
+			return p.parseObject(at)
+		}
+		return p.parseArray(at)
+	case c == '"':
+		return p.parseString()
+	case c == 't':
+		return true, p.parseLiteral("true")
+	case c == 'f':
+		return false, p.parseLiteral("false")
+	case c == 'n':
+		return nil, p.parseLiteral("null")
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) parseObject(at path) (interface{}, error) {
+	// consume {
+	p.off++
+	m := make(objx.Map)
+	p.skipWS()
+	if p.peek() == '}' {
+		p.off++
+		return m, nil
+	}
+	for {
+		p.skipWS()
+		if p.peek() != '"' {
+			return nil, p.unexpected()
+		}
+		keyPos := p.pos()
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		keyPath := at.key(key)
+		value, err := p.parseValue(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, found := m[key]; found {
+			p.duplicates = append(p.duplicates, duplicateKey{
+				selector: keyPath.selector,
+				pointer:  keyPath.pointer,
+				value:    value,
+				pos:      keyPos,
+			})
+		}
+		m[key] = value
+
+		p.skipWS()
+		switch p.peek() {
+		case ',':
+			p.off++
+		case '}':
+			p.off++
+			return m, nil
+		default:
+			return nil, p.unexpected()
+		}
+	}
+}
+
+func (p *parser) parseArray(at path) (interface{}, error) {
+	// consume [
+	p.off++
+	s := make([]interface{}, 0)
+	p.skipWS()
+	if p.peek() == ']' {
+		p.off++
+		return s, nil
+	}
+	for {
+		value, err := p.parseValue(at.index(len(s)))
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, value)
+
+		p.skipWS()
+		switch p.peek() {
+		case ',':
+			p.off++
+		case ']':
+			p.off++
+			return s, nil
+		default:
+			return nil, p.unexpected()
+		}
+	}
+}
+
+func (p *parser) parseString() (string, error) {
+	start := p.off
+	// consume "
+	p.off++
+	for p.off < len(p.data) {
+		switch c := p.data[p.off]; {
+		case c == '\\':
+			p.off += 2
+			continue
+		case c == '"':
+			p.off++
+			var s string
+			if err := json.Unmarshal(p.data[start:p.off], &s); err != nil {
+				p.off = start
+				return "", p.errorf("invalid string: %s", err)
+			}
+			return s, nil
+		case c < 0x20:
+			return "", p.errorf("invalid character %q in string literal", c)
+		}
+		p.off++
+	}
+	return "", p.errorf("unexpected end of JSON input")
+}
+
+func (p *parser) parseLiteral(literal string) error {
+	if len(p.data)-p.off < len(literal) || string(p.data[p.off:p.off+len(literal)]) != literal {
+		return p.unexpected()
+	}
+	p.off += len(literal)
+	return nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseNumber parses a number as specified by RFC 8259. It returns int if
+// number has no fraction, the same way objx.FromJSON does
+func (p *parser) parseNumber() (interface{}, error) {
+	start := p.off
+	if p.peek() == '-' {
+		p.off++
+	}
+	switch {
+	case p.peek() == '0':
+		p.off++
+	case isDigit(p.peek()):
+		for isDigit(p.peek()) {
+			p.off++
+		}
+	default:
+		return nil, p.unexpected()
+	}
+	if p.peek() == '.' {
+		p.off++
+		if !isDigit(p.peek()) {
+			return nil, p.unexpected()
+		}
+		for isDigit(p.peek()) {
+			p.off++
+		}
+	}
+	if p.peek() == 'e' || p.peek() == 'E' {
+		p.off++
+		if p.peek() == '+' || p.peek() == '-' {
+			p.off++
+		}
+		if !isDigit(p.peek()) {
+			return nil, p.unexpected()
+		}
+		for isDigit(p.peek()) {
+			p.off++
+		}
+	}
+	f, err := strconv.ParseFloat(string(p.data[start:p.off]), 64)
+	if err != nil {
+		p.off = start
+		return nil, p.errorf("invalid number: %s", err)
+	}
+	return normalize(f), nil
+}
@@ -0,0 +1,200 @@
+package jf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseDocument tests parser returns the same values as objx.FromJSON
+func TestParseDocument(t *testing.T) {
+	const js = `{
+        "int": 42,
+        "negative": -1,
+        "float": 11.1,
+        "exp": 1e3,
+        "string": "hello \"world\" é",
+        "bool": true,
+        "null": null,
+        "ints": [1, 2.5, {"nested": false}],
+        "map": {"empty": {}, "list": []}
+    }`
+
+	doc, err := parseDocument(js)
+	require.NoError(t, err)
+	expected, err := objx.FromJSON(js)
+	require.NoError(t, err)
+	assert.Equal(t, expected, doc.root)
+	assert.Len(t, doc.duplicates, 0)
+}
+
+// TestParseDocumentErrors tests malformed inputs are reported with positions
+func TestParseDocumentErrors(t *testing.T) {
+	testCases := []struct {
+		js  string
+		pos Position
+	}{
+		{`[1, 2]`, Position{0, 1, 1}},
+		{`{"a": 1,}`, Position{8, 1, 9}},
+		{"{\n  \"a\": 01\n}", Position{10, 2, 9}},
+		{`{"a": tru}`, Position{6, 1, 7}},
+		{`{"a": "b}`, Position{9, 1, 10}},
+		{`{"a": 1} x`, Position{9, 1, 10}},
+		{`{"a": 1.}`, Position{8, 1, 9}},
+		{`{"a": ` + strings.Repeat("[", maxNestingDepth), Position{10005, 1, 10006}},
+	}
+
+	for _, tt := range testCases {
+		_, err := parseDocument(tt.js)
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), "input %s", tt.js) {
+			assert.Equal(t, tt.pos, perr.Pos, "input %s: %s", tt.js, perr.Msg)
+		}
+	}
+
+	// the limit itself is fine
+	js := `{"a": ` + strings.Repeat("[", maxNestingDepth-1) + strings.Repeat("]", maxNestingDepth-1) + "}"
+	_, err := parseDocument(js)
+	assert.NoError(t, err)
+}
+
+const jsonDuplicateA = `{
+    "id": 1,
+    "data": {
+        "key": "foo",
+        "key": "bar"
+    }
+}`
+const jsonDuplicateB = `{
+    "id": 1,
+    "data": {
+        "key": "bar"
+    }
+}`
+
+// TestDuplicateKeys tests all modes of duplicate keys detection
+func TestDuplicateKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	// 1. the last value wins by default
+	lines, err := Diff(jsonDuplicateA, jsonDuplicateB)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+
+	// 2. error
+	_, err = NewDiffer().SetDuplicateKeys(DuplicateKeysError).Diff(jsonDuplicateA, jsonDuplicateB)
+	var derr *DuplicateKeyError
+	if assert.True(errors.As(err, &derr)) {
+		assert.Equal("data.key", derr.Selector)
+		assert.Equal(Position{Offset: 59, Line: 5, Column: 9}, derr.Pos)
+	}
+	assert.EqualError(err, `jsonA: line 5 column 9: duplicate key "data.key"`)
+
+	// 3. report as a diff
+	lines, err = NewDiffer().SetDuplicateKeys(DuplicateKeysReport).Diff(jsonDuplicateA, jsonDuplicateB)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+	assertLine(t, lines[0], "data.key", `"bar"`, "")
+	assert.Equal(KindDuplicateKey, lines[0].Kind())
+	assert.Equal(Position{Offset: 59, Line: 5, Column: 9}, lines[0].PosA())
+	assert.False(lines[0].PosB().IsValid())
+
+	// 4. ignore rules apply on duplicates too
+	lines, err = NewDiffer().
+		SetDuplicateKeys(DuplicateKeysReport).
+		AddIgnore(RuleA, re(t, `data\.key`)).
+		Diff(jsonDuplicateA, jsonDuplicateB)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+}
+
+// TestPositions tests each diff knows where values are in both inputs
+func TestPositions(t *testing.T) {
+	const jsonA = `{
+    "items": [
+        {"price": 1},
+        {"price": 2}
+    ],
+    "removed": true
+}`
+	const jsonB = `{
+  "items": [
+    {"price": 1},
+    {"price": 3, "added": null}
+  ]
+}`
+
+	assert := assert.New(t)
+	lines, err := Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 3)
+
+	assertLine(t, lines[0], "items[1].price", "2", "3")
+	assert.Equal(KindChanged, lines[0].Kind())
+	assert.Equal(Position{Offset: 57, Line: 4, Column: 19}, lines[0].PosA())
+	assert.Equal(Position{Offset: 47, Line: 4, Column: 15}, lines[0].PosB())
+
+	// missing value points to the parent
+	assertLine(t, lines[1], "items[1].added", "", "null")
+	assert.Equal(KindAdded, lines[1].Kind())
+	assert.Equal(Position{Offset: 47, Line: 4, Column: 9}, lines[1].PosA())
+	assert.Equal(Position{Offset: 59, Line: 4, Column: 27}, lines[1].PosB())
+
+	assertLine(t, lines[2], "removed", "true", "")
+	assert.Equal(KindRemoved, lines[2].Kind())
+	assert.Equal(Position{Offset: 82, Line: 6, Column: 16}, lines[2].PosA())
+	assert.Equal(Position{Offset: 0, Line: 1, Column: 1}, lines[2].PosB())
+}
+
+// TestParentPointer tests the pointer of a parent value
+func TestParentPointer(t *testing.T) {
+	assert.Equal(t, "/a/b/1", parentPointer("/a/b/1/c"))
+	assert.Equal(t, "/a/b", parentPointer("/a/b/1"))
+	assert.Equal(t, "", parentPointer("/a"))
+	assert.Equal(t, "", parentPointer("/0"))
+}
+
+// TestPath tests selectors and pointers of nested values
+func TestPath(t *testing.T) {
+	p := path{}.key("a.b").index(1).key("c/d~")
+	assert.Equal(t, "a.b[1].c/d~", p.selector)
+	assert.Equal(t, "/a.b/1/c~1d~0", p.pointer)
+	assert.Equal(t, p.pointer, p.pointerB)
+
+	p = path{}.key("a").indexAB(1, 0).key("b")
+	assert.Equal(t, "a[1].b", p.selector)
+	assert.Equal(t, "/a/1/b", p.pointer)
+	assert.Equal(t, "/a/0/b", p.pointerB)
+}
+
+// TestPositionsAmbiguousSelectors tests keys with dots and brackets, which
+// produce the same selector as nested values, get the right positions
+func TestPositionsAmbiguousSelectors(t *testing.T) {
+	const jsonA = `{
+  "a": {"b": 1},
+  "a.b": 2,
+  "c[0]": 3,
+  "c": [4]
+}`
+	const jsonB = `{
+  "a": {"b": 1},
+  "a.b": 20,
+  "c[0]": 30,
+  "c": [4]
+}`
+	lines, err := Diff(jsonA, jsonB)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "a.b", lines[0].Selector())
+	assert.Equal(t, 3, lines[0].PosA().Line)
+	assert.Equal(t, 10, lines[0].PosA().Column)
+	assert.Equal(t, "c[0]", lines[1].Selector())
+	assert.Equal(t, 4, lines[1].PosA().Line)
+	assert.Equal(t, 11, lines[1].PosB().Column)
+	assert.Equal(t, "/a.b", lines[0].PointerA())
+	assert.Equal(t, "/c[0]", lines[1].PointerB())
+}
@@ -0,0 +1,273 @@
+package jf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stretchr/objx"
+)
+
+var (
+	placeholderRe = regexp.MustCompile(`(?s)^\{\{([a-z0-9]+)(?::(.*))?\}\}$`)
+	uuidRe        = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// iso8601Layouts are accepted formats of {{iso8601}}
+var iso8601Layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// AddPlaceholders makes string values of matching keys like "{{uuid}}" to be
+// predicates, which the value on the other side must satisfy. Supported
+// placeholders are
+//
+//	{{any}}           any value, the key must be present
+//	{{uuid}}          UUID string
+//	{{regex:^v\d+$}}  string matching the regexp, numbers and bools are matched as JSON
+//	{{number}}        any number, {{number:>0}} compares it using >, >=, <, <=, == or !=
+//	{{iso8601}}       date or date and time string like 2020-01-02T15:04:05Z
+//
+// Typically placeholders are in expected JSON, so the rule is added for
+// RuleA. Unknown placeholders make Diff to fail.
+func (d *Differ) AddPlaceholders(dest ruleDest, selector *regexp.Regexp) *Differ {
+	return d.addRule(dest, &rule{selector: selector, action: placeholder})
+}
+
+// placeholderPred is a compiled placeholder
+type placeholderPred struct {
+	name string
+	// rg is a regexp of {{regex:...}}
+	rg *regexp.Regexp
+	// op and n are a comparison of {{number:>0}}, op is empty for {{number}}
+	op string
+	n  float64
+}
+
+// placeholders are compiled placeholders of a document by their string
+type placeholders map[string]*placeholderPred
+
+// parsePlaceholder returns name and argument of "{{name:argument}}"
+func parsePlaceholder(s string) (string, string, bool) {
+	m := placeholderRe.FindStringSubmatch(s)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// numberOps are operators of {{number:>0}}, longer first
+var numberOps = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// compilePlaceholder checks the placeholder and compiles its argument
+func compilePlaceholder(name, arg string) (*placeholderPred, error) {
+	pred := &placeholderPred{name: name}
+	switch name {
+	case "any", "uuid", "iso8601":
+	case "regex":
+		rg, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder {{regex:%s}}: %w", arg, err)
+		}
+		pred.rg = rg
+	case "number":
+		if arg == "" {
+			break
+		}
+		arg = strings.TrimSpace(arg)
+		for _, op := range numberOps {
+			if !strings.HasPrefix(arg, op) {
+				continue
+			}
+			n, err := strconv.ParseFloat(strings.TrimSpace(arg[len(op):]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid placeholder {{number:%s}}: %w", arg, err)
+			}
+			pred.op, pred.n = op, n
+			return pred, nil
+		}
+		return nil, fmt.Errorf("invalid placeholder {{number:%s}}, expected one of >, >=, <, <=, == or != and a number", arg)
+	default:
+		return nil, fmt.Errorf("unknown placeholder {{%s}}", name)
+	}
+	return pred, nil
+}
+
+// compareNumber evaluates {{number:>0}} like comparison for f
+func (pred *placeholderPred) compareNumber(f float64) bool {
+	switch pred.op {
+	case ">=":
+		return f >= pred.n
+	case "<=":
+		return f <= pred.n
+	case "==":
+		return f == pred.n
+	case "!=":
+		return f != pred.n
+	case ">":
+		return f > pred.n
+	case "<":
+		return f < pred.n
+	}
+	return true
+}
+
+// isISO8601 returns true if s is a date or date and time
+func isISO8601(s string) bool {
+	for _, layout := range iso8601Layouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if value satisfies the placeholder
+func (pred *placeholderPred) match(value *objx.Value) bool {
+	switch pred.name {
+	case "any":
+		return true
+	case "uuid":
+		return value.IsStr() && uuidRe.MatchString(value.MustStr())
+	case "regex":
+		switch {
+		case value.IsStr():
+			return pred.rg.MatchString(value.MustStr())
+		case value.IsInt() || value.IsFloat64() || value.IsBool():
+			return pred.rg.MatchString(jsonI{i: value}.JSON())
+		}
+		return false
+	case "number":
+		if !value.IsInt() && !value.IsFloat64() {
+			return false
+		}
+		return pred.compareNumber(mustFloat64(value))
+	case "iso8601":
+		return value.IsStr() && isISO8601(value.MustStr())
+	}
+	return false
+}
+
+// compilePlaceholders compiles placeholders of document A (or B) once it is
+// parsed, so they are not compiled on every comparison
+func (d *Differ) compilePlaceholders(p path, v interface{}, sideA bool, compiled placeholders) error {
+	switch value := v.(type) {
+	case objx.Map:
+		for key, item := range value {
+			if err := d.compilePlaceholders(p.key(key), item, sideA, compiled); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for idx, item := range value {
+			if err := d.compilePlaceholders(p.index(idx), item, sideA, compiled); err != nil {
+				return err
+			}
+		}
+	case string:
+		placeholdersA, placeholdersB := d.matchRule(p.selector, placeholder)
+		if (sideA && !placeholdersA) || (!sideA && !placeholdersB) {
+			return nil
+		}
+		if _, found := compiled[value]; found {
+			return nil
+		}
+		name, arg, found := parsePlaceholder(value)
+		if !found {
+			return nil
+		}
+		pred, err := compilePlaceholder(name, arg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.selector, err)
+		}
+		compiled[value] = pred
+	}
+	return nil
+}
+
+// initPlaceholders compiles placeholders of both inputs in advance, so
+// invalid ones fail even if the other side is missing
+func (d *Differ) initPlaceholders(rootA, rootB interface{}) error {
+	if !d.hasRule(placeholder) {
+		return nil
+	}
+	d.placeholders = make(placeholders)
+	if err := d.compilePlaceholders(path{}, rootA, true, d.placeholders); err != nil {
+		return err
+	}
+	return d.compilePlaceholders(path{}, rootB, false, d.placeholders)
+}
+
+// hasRule returns true if there is a rule with the action for A or B
+func (d *Differ) hasRule(action ruleAction) bool {
+	for _, rule := range d.rulesA {
+		if rule.action == action {
+			return true
+		}
+	}
+	for _, rule := range d.rulesB {
+		if rule.action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholderOf returns compiled placeholder if value is one
+func (d *Differ) placeholderOf(value *objx.Value) *placeholderPred {
+	if !value.IsStr() {
+		return nil
+	}
+	return d.placeholders[value.MustStr()]
+}
+
+// unescapedJSON does not escape <, > and &, so values like "{{number:>0}}"
+// are readable
+type unescapedJSON struct {
+	jsonI
+}
+
+func (u unescapedJSON) JSON() string {
+	inter := u.i
+	if v, ok := u.i.(*objx.Value); ok {
+		inter = v.Data()
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(inter); err != nil {
+		return u.jsonI.JSON()
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// diffPlaceholders compares values if one of them is a placeholder and
+// returns true in such case
+func (d *Differ) diffPlaceholders(p path, valueA, valueB *objx.Value) bool {
+	placeholdersA, placeholdersB := d.matchRule(p.selector, placeholder)
+	var pred *placeholderPred
+	value := valueB
+	if placeholdersA {
+		pred = d.placeholderOf(valueA)
+	}
+	if pred == nil && placeholdersB {
+		pred = d.placeholderOf(valueB)
+		value = valueA
+	}
+	if pred == nil {
+		return false
+	}
+
+	if !pred.match(value) {
+		d.lineAB(p, unescapedJSON{jsonI{i: valueA}}, unescapedJSON{jsonI{i: valueB}})
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package jf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceholders(t *testing.T) {
+	const jsonA = `{
+		"any": "{{any}}",
+		"anyObject": "{{any}}",
+		"id": "{{uuid}}",
+		"version": "{{regex:^v\\d+$}}",
+		"code": "{{regex:^4\\d\\d$}}",
+		"count": "{{number:>0}}",
+		"price": "{{number}}",
+		"created": "{{iso8601}}",
+		"date": "{{iso8601}}",
+		"literal": "{{any}}x"
+	}`
+	const jsonB = `{
+		"any": null,
+		"anyObject": {"a": [1]},
+		"id": "0b5b6a3c-8c59-4a3e-a6a3-6f0e2a7c1d2e",
+		"version": "v12",
+		"code": 404,
+		"count": 3,
+		"price": 1.5,
+		"created": "2020-01-02T15:04:05.123+01:00",
+		"date": "2020-01-02",
+		"literal": "{{any}}x"
+	}`
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().AddPlaceholders(RuleA, re(t, `.*`)).Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+
+	// without the rule placeholders are plain strings
+	lines, err = NewDiffer().Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 9)
+}
+
+func TestPlaceholdersMismatch(t *testing.T) {
+	const jsonA = `{"id": "{{uuid}}", "v": "{{regex:^v\\d+$}}", "n": "{{number:<=0}}", "s": "{{number}}", "t": "{{iso8601}}", "list": ["{{any}}", "{{any}}"]}`
+	const jsonB = `{"id": "42", "v": "version", "n": 1, "s": "1", "t": "yesterday", "list": [1]}`
+
+	assert := assert.New(t)
+	lines, err := NewDiffer().AddPlaceholders(RuleA, re(t, `.*`)).Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 6)
+	assertLine(t, lines[0], "id", `"{{uuid}}"`, `"42"`)
+	assertLine(t, lines[1], "list[1]", `"{{any}}"`, "")
+	assertLine(t, lines[2], "n", `"{{number:<=0}}"`, "1")
+
+	// placeholders in B
+	lines, err = NewDiffer().AddPlaceholders(RuleB, re(t, `.*`)).Diff(`{"id": 1}`, `{"id": "{{number:==1}}"}`)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+}
+
+func TestPlaceholdersErrors(t *testing.T) {
+	assert := assert.New(t)
+	d := NewDiffer().AddPlaceholders(RuleA, re(t, `.*`))
+	for _, placeholder := range []string{`{{foo}}`, `{{regex:(}}`, `{{number:~1}}`, `{{number:>x}}`} {
+		_, err := d.Diff(`{"a": "`+placeholder+`"}`, `{"a": 1}`)
+		assert.Error(err, placeholder)
+	}
+}
+
+func TestCompilePlaceholders(t *testing.T) {
+	doc, err := parseDocument(`{"a": {"v": "{{regex:^v\\d+$}}", "n": ["{{number:>=1}}", "{{regex:^v\\d+$}}"]}, "b": "{{regex:(}}"}`)
+	assert.NoError(t, err)
+
+	// b is not matched by the rule, so the invalid regexp is not compiled
+	d := NewDiffer().AddPlaceholders(RuleA, re(t, `^a`))
+	compiled := make(placeholders)
+	assert.NoError(t, d.compilePlaceholders(path{}, doc.root, true, compiled))
+	assert.Len(t, compiled, 2)
+	assert.NotNil(t, compiled[`{{regex:^v\d+$}}`].rg)
+	assert.Equal(t, ">=", compiled[`{{number:>=1}}`].op)
+	assert.Equal(t, 1.0, compiled[`{{number:>=1}}`].n)
+
+	// rule for B only
+	compiled = make(placeholders)
+	assert.NoError(t, d.compilePlaceholders(path{}, doc.root, false, compiled))
+	assert.Len(t, compiled, 0)
+
+	d = NewDiffer().AddPlaceholders(RuleA, re(t, `.*`))
+	err = d.compilePlaceholders(path{}, doc.root, true, make(placeholders))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "b: invalid placeholder {{regex:(}}")
+
+	// invalid placeholder is reported even if the other side is missing
+	_, err = d.Diff(`{"a": "{{number:~1}}"}`, `{}`)
+	assert.EqualError(t, err, "a: invalid placeholder {{number:~1}}, expected one of >, >=, <, <=, == or != and a number")
+}
+
+// TestPlaceholdersDiffGo tests placeholders in Go values
+func TestPlaceholdersDiffGo(t *testing.T) {
+	d := NewDiffer().AddPlaceholders(RuleA, re(t, `.*`))
+	lines, err := d.DiffGo(map[string]interface{}{"id": "{{number:>0}}"}, map[string]interface{}{"id": 1})
+	assert.NoError(t, err)
+	assert.Len(t, lines, 0)
+
+	lines, err = d.DiffGo(map[string]interface{}{"id": "{{number:>0}}"}, map[string]interface{}{"id": 0})
+	assert.NoError(t, err)
+	assert.Len(t, lines, 1)
+
+	// compiled in advance like in Diff
+	_, err = d.DiffGo(map[string]interface{}{"a": "{{number:~1}}"}, map[string]interface{}{})
+	assert.EqualError(t, err, "a: invalid placeholder {{number:~1}}, expected one of >, >=, <, <=, == or != and a number")
+
+	// not matched by the rule, so it is a plain string
+	lines, err = NewDiffer().AddPlaceholders(RuleA, re(t, `^id$`)).DiffGo(
+		map[string]interface{}{"name": "{{any}}"}, map[string]interface{}{"name": "joe"})
+	assert.NoError(t, err)
+	assert.Len(t, lines, 1)
+}
+
+// TestPlaceholdersEscape tests only placeholders are not HTML escaped
+func TestPlaceholdersEscape(t *testing.T) {
+	lines, err := NewDiffer().AddPlaceholders(RuleA, re(t, `^n$`)).Diff(`{"n": "{{number:>0}}", "s": "<a>"}`, `{"n": 0, "s": "<b>"}`)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 2)
+	assertLine(t, lines[0], "n", `"{{number:>0}}"`, "0")
+	// other values are encoded by json.Marshal as before
+	assertLine(t, lines[1], "s", `"\u003ca\u003e"`, `"\u003cb\u003e"`)
+}
@@ -0,0 +1,767 @@
+package jf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stretchr/objx"
+)
+
+// maxSchemaDepth stops the validation of recursive $ref without data below
+const maxSchemaDepth = 64
+
+// Schema is a JSON Schema. Validation supports a subset of draft 2020-12
+//
+//	type, enum, const, $ref to the same document ("#/$defs/item"), allOf,
+//	anyOf, oneOf, not, if, then, else, minimum, maximum, exclusiveMinimum,
+//	exclusiveMaximum, multipleOf, minLength, maxLength, pattern, items,
+//	prefixItems, contains, minContains, maxContains, minItems, maxItems,
+//	uniqueItems, unevaluatedItems, properties, patternProperties,
+//	additionalProperties, propertyNames, unevaluatedProperties, required,
+//	dependentRequired, dependentSchemas, minProperties and maxProperties
+//
+// Annotation keywords, like format or description, are ignored.
+type Schema struct {
+	root interface{}
+}
+
+// ParseSchema parses JSON Schema, the top level must be an object
+func ParseSchema(js string) (*Schema, error) {
+	doc, err := parseDocument(js)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{root: doc.root}, nil
+}
+
+// LoadSchema reads JSON Schema from a local file
+func LoadSchema(path string) (*Schema, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseSchema(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// SchemaViolation is a value, which does not conform to the schema
+type SchemaViolation struct {
+	// Selector of the value in the same format as SingleDiff uses
+	Selector string
+	// Message like "must be integer"
+	Message string
+	// value is the invalid value, missing for required properties
+	value   interface{}
+	missing bool
+	// pointer is used for the position lookup
+	pointer string
+}
+
+// Validate checks js against the schema
+func (s *Schema) Validate(js string) ([]SchemaViolation, error) {
+	doc, err := parseDocument(js)
+	if err != nil {
+		return nil, err
+	}
+	return s.validate(doc.root), nil
+}
+
+func (s *Schema) validate(value interface{}) []SchemaViolation {
+	v := &schemaValidator{root: s.root}
+	v.validate(path{}, s.root, value, 0)
+	return v.violations
+}
+
+// resolvePointer resolves local $ref like "#/$defs/item"
+func resolvePointer(root interface{}, ref string) (interface{}, error) {
+	if ref == "#" {
+		return root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q, only local references are supported", ref)
+	}
+	current := root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch c := current.(type) {
+		case objx.Map:
+			next, found := c[token]
+			if !found {
+				return nil, fmt.Errorf("$ref %q not found", ref)
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(c) {
+				return nil, fmt.Errorf("$ref %q not found", ref)
+			}
+			current = c[idx]
+		default:
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+	}
+	return current, nil
+}
+
+// jsonType returns JSON Schema type name of a value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int:
+		return "integer"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case objx.Map:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaNumber returns number keyword of a schema
+func schemaNumber(schema objx.Map, key string) (float64, bool) {
+	switch n := schema[key].(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// schemaInt returns integer keyword of a schema
+func schemaInt(schema objx.Map, key string) (int, bool) {
+	n, ok := schema[key].(int)
+	return n, ok
+}
+
+// toFloat returns number value as float64
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toJSONText(value interface{}) string {
+	return jsonI{i: value}.JSON()
+}
+
+type schemaValidator struct {
+	root       interface{}
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) errorf(at path, value interface{}, format string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{
+		Selector: at.selector,
+		pointer:  at.pointer,
+		Message:  fmt.Sprintf(format, args...),
+		value:    value,
+	})
+}
+
+// missing reports a missing property
+func (v *schemaValidator) missing(at path, message string) {
+	v.violations = append(v.violations, SchemaViolation{
+		Selector: at.selector,
+		pointer:  at.pointer,
+		Message:  message,
+		missing:  true,
+	})
+}
+
+// valid returns true if value conforms to schema, nothing is reported
+func (v *schemaValidator) valid(at path, schema, value interface{}, depth int) bool {
+	other := &schemaValidator{root: v.root}
+	other.validate(at, schema, value, depth)
+	return len(other.violations) == 0
+}
+
+func (v *schemaValidator) validate(at path, schema, value interface{}, depth int) {
+	if depth > maxSchemaDepth {
+		v.errorf(at, value, "schema is nested too deep, recursive $ref?")
+		return
+	}
+	var s objx.Map
+	switch typed := schema.(type) {
+	case bool:
+		if !typed {
+			v.errorf(at, value, "is not allowed")
+		}
+		return
+	case objx.Map:
+		s = typed
+	default:
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := resolvePointer(v.root, ref)
+		if err != nil {
+			v.errorf(at, value, "%s", err)
+		} else {
+			v.validate(at, target, value, depth+1)
+		}
+	}
+
+	v.validateGeneric(at, s, value, depth)
+	switch typed := value.(type) {
+	case int, float64:
+		f, _ := toFloat(typed)
+		v.validateNumber(at, s, f, value)
+	case string:
+		v.validateString(at, s, typed)
+	case []interface{}:
+		v.validateArray(at, s, typed, depth)
+	case objx.Map:
+		v.validateObject(at, s, typed, depth)
+	}
+}
+
+func (v *schemaValidator) validateGeneric(at path, s objx.Map, value interface{}, depth int) {
+	var types []string
+	switch t := s["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			if str, ok := name.(string); ok {
+				types = append(types, str)
+			}
+		}
+	}
+	if len(types) > 0 {
+		actual := jsonType(value)
+		ok := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			v.errorf(at, value, "must be %s", strings.Join(types, " or "))
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.errorf(at, value, "must be one of %s", toJSONText(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		v.errorf(at, value, "must be %s", toJSONText(c))
+	}
+
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(at, sub, value, depth+1)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		found := false
+		for _, sub := range anyOf {
+			if v.valid(at, sub, value, depth+1) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.errorf(at, value, "must match at least one schema of anyOf")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if v.valid(at, sub, value, depth+1) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.errorf(at, value, "must match exactly one schema of oneOf, matches %d", matches)
+		}
+	}
+	if not, ok := s["not"]; ok && v.valid(at, not, value, depth+1) {
+		v.errorf(at, value, "must not match the schema of not")
+	}
+	if cond, ok := s["if"]; ok {
+		if v.valid(at, cond, value, depth+1) {
+			if then, ok := s["then"]; ok {
+				v.validate(at, then, value, depth+1)
+			}
+		} else if els, ok := s["else"]; ok {
+			v.validate(at, els, value, depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(at path, s objx.Map, f float64, value interface{}) {
+	if min, ok := schemaNumber(s, "minimum"); ok && f < min {
+		v.errorf(at, value, "must be >= %s", toJSONText(s["minimum"]))
+	}
+	if max, ok := schemaNumber(s, "maximum"); ok && f > max {
+		v.errorf(at, value, "must be <= %s", toJSONText(s["maximum"]))
+	}
+	if min, ok := schemaNumber(s, "exclusiveMinimum"); ok && f <= min {
+		v.errorf(at, value, "must be > %s", toJSONText(s["exclusiveMinimum"]))
+	}
+	if max, ok := schemaNumber(s, "exclusiveMaximum"); ok && f >= max {
+		v.errorf(at, value, "must be < %s", toJSONText(s["exclusiveMaximum"]))
+	}
+	if m, ok := schemaNumber(s, "multipleOf"); ok && m > 0 {
+		q := f / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			v.errorf(at, value, "must be multiple of %s", toJSONText(s["multipleOf"]))
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(at path, s objx.Map, str string) {
+	length := utf8.RuneCountInString(str)
+	if min, ok := schemaInt(s, "minLength"); ok && length < min {
+		v.errorf(at, str, "must be at least %d characters long", min)
+	}
+	if max, ok := schemaInt(s, "maxLength"); ok && length > max {
+		v.errorf(at, str, "must be at most %d characters long", max)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		rg, err := regexp.Compile(pattern)
+		if err != nil {
+			v.errorf(at, str, "invalid pattern %q: %s", pattern, err)
+		} else if !rg.MatchString(str) {
+			v.errorf(at, str, "must match %q", pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(at path, s objx.Map, a []interface{}, depth int) {
+	prefix := 0
+	if prefixItems, ok := s["prefixItems"].([]interface{}); ok {
+		for idx, sub := range prefixItems {
+			if idx >= len(a) {
+				break
+			}
+			v.validate(at.index(idx), sub, a[idx], depth+1)
+		}
+		prefix = len(prefixItems)
+	}
+	if items, ok := s["items"]; ok {
+		for idx := prefix; idx < len(a); idx++ {
+			v.validate(at.index(idx), items, a[idx], depth+1)
+		}
+	}
+	if contains, ok := s["contains"]; ok {
+		matches := 0
+		for idx, item := range a {
+			if v.valid(at.index(idx), contains, item, depth+1) {
+				matches++
+			}
+		}
+		min, hasMin := schemaInt(s, "minContains")
+		if !hasMin {
+			min = 1
+		}
+		switch {
+		case !hasMin && matches == 0:
+			v.errorf(at, a, "must contain a matching item")
+		case matches < min:
+			v.errorf(at, a, "must contain at least %d matching items", min)
+		}
+		if max, ok := schemaInt(s, "maxContains"); ok && matches > max {
+			v.errorf(at, a, "must contain at most %d matching items", max)
+		}
+	}
+	if unevaluated, ok := s["unevaluatedItems"]; ok {
+		evaluated := v.evaluatedItems(at, s, a, depth)
+		for idx := range a {
+			if !evaluated[idx] {
+				v.validate(at.index(idx), unevaluated, a[idx], depth+1)
+			}
+		}
+	}
+	if min, ok := schemaInt(s, "minItems"); ok && len(a) < min {
+		v.errorf(at, a, "must have at least %d items", min)
+	}
+	if max, ok := schemaInt(s, "maxItems"); ok && len(a) > max {
+		v.errorf(at, a, "must have at most %d items", max)
+	}
+	if unique, ok := s["uniqueItems"].(bool); ok && unique {
+	unique:
+		for i := range a {
+			for j := i + 1; j < len(a); j++ {
+				if reflect.DeepEqual(a[i], a[j]) {
+					v.errorf(at, a, "must have unique items, [%d] and [%d] are equal", i, j)
+					break unique
+				}
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(at path, s objx.Map, m objx.Map, depth int) {
+	properties, _ := s["properties"].(objx.Map)
+	patternProperties, _ := s["patternProperties"].(objx.Map)
+	for _, key := range sortedKeys(m) {
+		keyPath := at.key(key)
+		matched := false
+		if sub, found := properties[key]; found {
+			v.validate(keyPath, sub, m[key], depth+1)
+			matched = true
+		}
+		for _, pattern := range sortedKeys(patternProperties) {
+			rg, err := regexp.Compile(pattern)
+			if err != nil {
+				v.errorf(keyPath, m[key], "invalid pattern %q: %s", pattern, err)
+				continue
+			}
+			if rg.MatchString(key) {
+				v.validate(keyPath, patternProperties[pattern], m[key], depth+1)
+				matched = true
+			}
+		}
+		if additional, found := s["additionalProperties"]; found && !matched {
+			v.validate(keyPath, additional, m[key], depth+1)
+		}
+		if names, found := s["propertyNames"]; found && !v.valid(keyPath, names, key, depth+1) {
+			v.errorf(keyPath, m[key], "property name %q does not match propertyNames", key)
+		}
+	}
+
+	if required, ok := s["required"].([]interface{}); ok {
+		missing := make([]string, 0)
+		for _, r := range required {
+			key, ok := r.(string)
+			if !ok {
+				continue
+			}
+			if _, found := m[key]; !found {
+				missing = append(missing, key)
+			}
+		}
+		sort.Strings(missing)
+		for _, key := range missing {
+			v.missing(at.key(key), "is required")
+		}
+	}
+	if dependent, ok := s["dependentRequired"].(objx.Map); ok {
+		for _, key := range sortedKeys(dependent) {
+			if _, found := m[key]; !found {
+				continue
+			}
+			required, _ := dependent[key].([]interface{})
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, found := m[name]; !found {
+						v.missing(at.key(name), fmt.Sprintf("is required by %q", key))
+					}
+				}
+			}
+		}
+	}
+	if dependent, ok := s["dependentSchemas"].(objx.Map); ok {
+		for _, key := range sortedKeys(dependent) {
+			if _, found := m[key]; found {
+				v.validate(at, dependent[key], m, depth+1)
+			}
+		}
+	}
+	if min, ok := schemaInt(s, "minProperties"); ok && len(m) < min {
+		v.errorf(at, m, "must have at least %d properties", min)
+	}
+	if max, ok := schemaInt(s, "maxProperties"); ok && len(m) > max {
+		v.errorf(at, m, "must have at most %d properties", max)
+	}
+	if unevaluated, ok := s["unevaluatedProperties"]; ok {
+		evaluated := v.evaluatedProperties(at, s, m, depth)
+		for _, key := range sortedKeys(m) {
+			if !evaluated[key] {
+				v.validate(at.key(key), unevaluated, m[key], depth+1)
+			}
+		}
+	}
+}
+
+// applied returns subschemas of s, which apply to value at the same
+// location: $ref, allOf, valid anyOf and oneOf, if with then or else and
+// dependentSchemas. Annotations of them count for unevaluated* keywords.
+func (v *schemaValidator) applied(at path, s objx.Map, value interface{}, depth int) []objx.Map {
+	var ret []objx.Map
+	add := func(sub interface{}) {
+		if m, ok := sub.(objx.Map); ok {
+			ret = append(ret, m)
+		}
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		if target, err := resolvePointer(v.root, ref); err == nil {
+			add(target)
+		}
+	}
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			add(sub)
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if list, ok := s[keyword].([]interface{}); ok {
+			for _, sub := range list {
+				if v.valid(at, sub, value, depth+1) {
+					add(sub)
+				}
+			}
+		}
+	}
+	if cond, ok := s["if"]; ok {
+		if v.valid(at, cond, value, depth+1) {
+			add(cond)
+			add(s["then"])
+		} else {
+			add(s["else"])
+		}
+	}
+	if m, ok := value.(objx.Map); ok {
+		if dependent, ok := s["dependentSchemas"].(objx.Map); ok {
+			for _, key := range sortedKeys(dependent) {
+				if _, found := m[key]; found {
+					add(dependent[key])
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// evaluatedProperties returns keys of m evaluated by s or its applied
+// subschemas
+func (v *schemaValidator) evaluatedProperties(at path, s objx.Map, m objx.Map, depth int) map[string]bool {
+	evaluated := make(map[string]bool)
+	if depth > maxSchemaDepth {
+		return evaluated
+	}
+	properties, _ := s["properties"].(objx.Map)
+	patternProperties, _ := s["patternProperties"].(objx.Map)
+	_, additional := s["additionalProperties"]
+	for key := range m {
+		if _, found := properties[key]; found || additional {
+			evaluated[key] = true
+			continue
+		}
+		for pattern := range patternProperties {
+			if rg, err := regexp.Compile(pattern); err == nil && rg.MatchString(key) {
+				evaluated[key] = true
+				break
+			}
+		}
+	}
+	for _, sub := range v.applied(at, s, m, depth) {
+		_, unevaluated := sub["unevaluatedProperties"]
+		for key := range v.evaluatedProperties(at, sub, m, depth+1) {
+			evaluated[key] = true
+		}
+		for key := range m {
+			evaluated[key] = evaluated[key] || unevaluated
+		}
+	}
+	return evaluated
+}
+
+// evaluatedItems returns indexes of a evaluated by s or its applied
+// subschemas
+func (v *schemaValidator) evaluatedItems(at path, s objx.Map, a []interface{}, depth int) map[int]bool {
+	evaluated := make(map[int]bool)
+	if depth > maxSchemaDepth {
+		return evaluated
+	}
+	_, items := s["items"]
+	prefixItems, _ := s["prefixItems"].([]interface{})
+	contains, hasContains := s["contains"]
+	for idx, item := range a {
+		if items || idx < len(prefixItems) ||
+			(hasContains && v.valid(at.index(idx), contains, item, depth+1)) {
+			evaluated[idx] = true
+		}
+	}
+	for _, sub := range v.applied(at, s, a, depth) {
+		_, unevaluated := sub["unevaluatedItems"]
+		for idx := range v.evaluatedItems(at, sub, a, depth+1) {
+			evaluated[idx] = true
+		}
+		for idx := range a {
+			evaluated[idx] = evaluated[idx] || unevaluated
+		}
+	}
+	return evaluated
+}
+
+// joinPattern joins regexp patterns of selectors
+func joinPattern(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + `\.` + key
+}
+
+// walk calls fn for each subschema with a regexp pattern matching selectors
+// of values it describes. The pattern is empty for the top level.
+func (s *Schema) walk(fn func(pattern string, schema objx.Map)) {
+	s.walkSchema("", s.root, fn, make(map[string]bool))
+}
+
+func (s *Schema) walkSchema(pattern string, schema interface{}, fn func(string, objx.Map), refs map[string]bool) {
+	m, ok := schema.(objx.Map)
+	if !ok {
+		return
+	}
+	fn(pattern, m)
+
+	// stop on recursive references
+	if ref, ok := m["$ref"].(string); ok && !refs[ref] {
+		if target, err := resolvePointer(s.root, ref); err == nil {
+			refs[ref] = true
+			s.walkSchema(pattern, target, fn, refs)
+			delete(refs, ref)
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		if list, ok := m[keyword].([]interface{}); ok {
+			for _, sub := range list {
+				s.walkSchema(pattern, sub, fn, refs)
+			}
+		}
+	}
+	if properties, ok := m["properties"].(objx.Map); ok {
+		for _, key := range sortedKeys(properties) {
+			s.walkSchema(joinPattern(pattern, regexp.QuoteMeta(key)), properties[key], fn, refs)
+		}
+	}
+	if additional, ok := m["additionalProperties"]; ok {
+		s.walkSchema(joinPattern(pattern, `[^.\[]+`), additional, fn, refs)
+	}
+	if prefixItems, ok := m["prefixItems"].([]interface{}); ok {
+		for idx, sub := range prefixItems {
+			s.walkSchema(fmt.Sprintf(`%s\[%d\]`, pattern, idx), sub, fn, refs)
+		}
+	}
+	if items, ok := m["items"]; ok {
+		s.walkSchema(pattern+`\[\d+\]`, items, fn, refs)
+	}
+}
+
+// addIgnoreRules ignores properties annotated by "x-jf-ignore": true
+func (d *Differ) addIgnoreRules(pattern string, schema objx.Map) {
+	if ignore, _ := schema["x-jf-ignore"].(bool); ignore && pattern != "" {
+		d.AddIgnore(RuleAB, regexp.MustCompile("^"+pattern+"$"))
+	}
+}
+
+// SetSchema validates jsonA and jsonB against the schema, violations are
+// reported as KindSchema. Properties annotated by "x-jf-ignore": true are
+// ignored in both inputs.
+func (d *Differ) SetSchema(s *Schema) *Differ {
+	d.schema = s
+	s.walk(d.addIgnoreRules)
+	return d
+}
+
+// isNullable returns true for OpenAPI "nullable": true or type with null
+func isNullable(schema objx.Map) bool {
+	if nullable, _ := schema["nullable"].(bool); nullable {
+		return true
+	}
+	if types, ok := schema["type"].([]interface{}); ok {
+		for _, t := range types {
+			if t == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddSchemaRules derives rules from the schema, so they follow the API
+// definition
+//
+//	"uniqueItems": true        ignore order of the array
+//	"nullable": true           coerce null, the same for "type": [..., "null"]
+//	"multipleOf": 0.01         floats are equal if they differ less than a half of it
+//	"x-jf-array-key": "id"     match elements of the array by "id", see AddArrayKey
+//	"x-jf-ignore": true        ignore the property
+//
+// Schema is not used for validation, see SetSchema.
+func (d *Differ) AddSchemaRules(s *Schema) *Differ {
+	s.walk(func(pattern string, schema objx.Map) {
+		d.addIgnoreRules(pattern, schema)
+		if pattern == "" {
+			return
+		}
+		rg := regexp.MustCompile("^" + pattern + "$")
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+			d.AddIgnoreOrder(rg)
+		}
+		if isNullable(schema) {
+			d.AddCoerceNull(RuleAB, rg)
+		}
+		if m, ok := schemaNumber(schema, "multipleOf"); ok && m > 0 {
+			d.AddFloatEqual(rg, func(a, b float64) bool { return math.Abs(a-b) < m/2 })
+		}
+		if key, ok := schema["x-jf-array-key"].(string); ok {
+			d.AddArrayKey(rg, key)
+		}
+	})
+	return d
+}
+
+// lineSchema reports schema violations of jsonA (isA) or jsonB
+func (d *Differ) lineSchema(violations []SchemaViolation, isA bool) {
+	for _, v := range violations {
+		ignoreA, ignoreB := d.shouldIgnore(v.Selector)
+		if (isA && ignoreA) || (!isA && ignoreB) {
+			continue
+		}
+		value := ""
+		if !v.missing {
+			value = toJSONText(v.value)
+		}
+		line := SingleDiff{
+			selector: v.Selector,
+			kind:     KindSchema,
+			message:  v.Message,
+		}
+		if isA {
+			line.valueA = value
+			line.posA = d.positionsA.lookup(v.pointer)
+			line.pointerA = v.pointer
+		} else {
+			line.valueB = value
+			line.posB = d.positionsB.lookup(v.pointer)
+			line.pointerB = v.pointer
+		}
+		d.diff = append(d.diff, line)
+	}
+}
@@ -0,0 +1,222 @@
+package jf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 1, "maxLength": 5, "pattern": "^[a-z]+$"},
+		"price": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.01},
+		"status": {"enum": ["new", "done"]},
+		"kind": {"const": "user"},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"point": {"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}], "items": false},
+		"owner": {"$ref": "#/$defs/owner"},
+		"created": {"type": "string", "x-jf-ignore": true},
+		"value": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+		"one": {"oneOf": [{"type": "integer"}, {"type": "number"}]},
+		"not": {"not": {"type": "null"}}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"owner": {
+			"type": "object",
+			"properties": {"name": {"type": "string"}, "parent": {"$ref": "#/$defs/owner"}},
+			"required": ["name"]
+		}
+	}
+}`
+
+func testValidate(t *testing.T, js string) []SchemaViolation {
+	s, err := ParseSchema(testSchema)
+	assert.NoError(t, err)
+	violations, err := s.Validate(js)
+	assert.NoError(t, err)
+	return violations
+}
+
+func TestSchemaValid(t *testing.T) {
+	violations := testValidate(t, `{
+		"id": 1, "name": "foo", "price": 1.23, "status": "new", "kind": "user",
+		"tags": ["a", "b"], "point": [1, 2.5], "owner": {"name": "x", "parent": {"name": "y"}},
+		"created": "now", "value": null, "one": 1.5, "not": 1
+	}`)
+	assert.Len(t, violations, 0)
+}
+
+func TestSchemaViolations(t *testing.T) {
+	violations := testValidate(t, `{
+		"id": 0, "name": "Foo bar", "price": 1.234, "status": "old", "kind": "admin",
+		"tags": ["a", "a", 1, "b"], "point": [1, 2, 3], "owner": {"parent": {}},
+		"value": 1, "one": 1, "not": null, "extra": true
+	}`)
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, v.Selector+": "+v.Message)
+	}
+	assert.Equal(t, []string{
+		"extra: is not allowed",
+		"id: must be >= 1",
+		"kind: must be \"user\"",
+		"name: must be at most 5 characters long",
+		"name: must match \"^[a-z]+$\"",
+		"not: must not match the schema of not",
+		"one: must match exactly one schema of oneOf, matches 2",
+		"owner.parent.name: is required",
+		"owner.name: is required",
+		"point[2]: is not allowed",
+		"price: must be multiple of 0.01",
+		"status: must be one of [\"new\",\"done\"]",
+		"tags[2]: must be string",
+		"tags: must have at most 3 items",
+		"tags: must have unique items, [0] and [1] are equal",
+		"value: must match at least one schema of anyOf",
+	}, messages)
+}
+
+// TestSchemaApplicators tests conditional, dependent and unevaluated keywords
+func TestSchemaApplicators(t *testing.T) {
+	const schema = `{
+		"properties": {
+			"kind": {"enum": ["a", "b"]},
+			"list": {
+				"prefixItems": [{"type": "string"}],
+				"contains": {"type": "integer"},
+				"minContains": 2,
+				"maxContains": 3,
+				"unevaluatedItems": {"type": "integer"}
+			},
+			"names": {"propertyNames": {"pattern": "^[a-z]+$"}}
+		},
+		"if": {"properties": {"kind": {"const": "a"}}},
+		"then": {"required": ["a"], "properties": {"a": {"type": "integer"}}},
+		"else": {"properties": {"b": {"type": "string"}}},
+		"dependentRequired": {"card": ["address"]},
+		"dependentSchemas": {"card": {"properties": {"card": {"type": "string"}, "address": true}}},
+		"unevaluatedProperties": false
+	}`
+	assert := assert.New(t)
+	s, err := ParseSchema(schema)
+	assert.NoError(err)
+
+	for _, js := range []string{
+		`{"kind": "a", "a": 1, "list": ["x", 1, 2], "names": {"ok": 1}}`,
+		`{"kind": "b", "b": "x", "card": "1", "address": "here"}`,
+	} {
+		violations, err := s.Validate(js)
+		assert.NoError(err)
+		assert.Len(violations, 0, js)
+	}
+
+	messages := func(js string) []string {
+		violations, err := s.Validate(js)
+		assert.NoError(err)
+		ret := make([]string, 0, len(violations))
+		for _, v := range violations {
+			ret = append(ret, v.Selector+": "+v.Message)
+		}
+		return ret
+	}
+	assert.Equal([]string{
+		"a: is required",
+		"list: must contain at least 2 matching items",
+		"list[1]: must be integer",
+		"names.Bad: property name \"Bad\" does not match propertyNames",
+		"b: is not allowed",
+	}, messages(`{"kind": "a", "b": "x", "list": ["x", "y", 1], "names": {"Bad": 1}}`))
+	assert.Equal([]string{
+		"b: must be string",
+		"list[0]: must be string",
+		"list: must contain at most 3 matching items",
+		"address: is required by \"card\"",
+		"card: must be string",
+	}, messages(`{"kind": "b", "b": 1, "card": 1, "list": [1, 2, 3, 4]}`))
+}
+
+func TestSchemaErrors(t *testing.T) {
+	assert := assert.New(t)
+	_, err := ParseSchema(`[]`)
+	assert.Error(err)
+
+	s, err := ParseSchema(`{"properties": {"a": {"$ref": "http://example.com/a.json"}, "b": {"$ref": "#/$defs/b"}, "c": {"$ref": "#"}}}`)
+	assert.NoError(err)
+	violations, err := s.Validate(`{"a": 1, "b": 2, "c": {"c": {"c": {}}}}`)
+	assert.NoError(err)
+	assert.Len(violations, 2)
+	assert.Contains(violations[0].Message, "only local references are supported")
+	assert.Contains(violations[1].Message, `$ref "#/$defs/b" not found`)
+
+	_, err = LoadSchema("testdata/missing.json")
+	assert.Error(err)
+}
+
+func TestDiffSchema(t *testing.T) {
+	const jsonA = `{"id": 1, "name": "foo", "created": 42}`
+	const jsonB = `{"id": "2", "created": "now"}`
+
+	assert := assert.New(t)
+	s, err := ParseSchema(testSchema)
+	assert.NoError(err)
+	lines, err := NewDiffer().SetSchema(s).Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 4)
+
+	assert.Equal(KindSchema, lines[0].Kind())
+	assertLine(t, lines[0], "id", "", `"2"`)
+	assert.Equal("must be integer", lines[0].Message())
+	assert.Equal(Position{Offset: 7, Line: 1, Column: 8}, lines[0].PosB())
+	assertLine(t, lines[1], "name", "", "")
+	assert.Equal("is required", lines[1].Message())
+	assertLine(t, lines[2], "id", "1", `"2"`)
+	assertLine(t, lines[3], "name", `"foo"`, "")
+
+	assert.Equal([]string{"ignore AB ^created$"}, NewDiffer().SetSchema(s).Rules())
+}
+
+func TestAddSchemaRules(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"properties": {
+			"tags": {"type": "array", "uniqueItems": true},
+			"note": {"type": "string", "nullable": true},
+			"count": {"type": ["integer", "null"]},
+			"price": {"type": "number", "multipleOf": 0.01},
+			"items": {"type": "array", "x-jf-array-key": "id", "items": {"$ref": "#/$defs/item"}},
+			"created": {"x-jf-ignore": true}
+		},
+		"$defs": {"item": {"properties": {"sizes": {"uniqueItems": true}}}}
+	}`
+	const jsonA = `{"tags": ["a", "b"], "note": null, "count": 0, "price": 1.001, "created": 1,
+		"items": [{"id": 1, "sizes": [1, 2]}, {"id": 2}]}`
+	const jsonB = `{"tags": ["b", "a"], "note": "", "count": null, "price": 1.004, "created": 2,
+		"items": [{"id": 2}, {"id": 1, "sizes": [2, 1]}]}`
+
+	assert := assert.New(t)
+	s, err := ParseSchema(schema)
+	assert.NoError(err)
+	d := NewDiffer().AddSchemaRules(s)
+	assert.Equal([]string{
+		"coerce-null AB ^count$",
+		"ignore AB ^created$",
+		"array-key AB ^items$",
+		`ignore-order AB ^items\[\d+\]\.sizes$`,
+		"coerce-null AB ^note$",
+		"float-equal AB ^price$",
+		"ignore-order AB ^tags$",
+	}, d.Rules())
+
+	lines, err := d.Diff(jsonA, jsonB)
+	assert.NoError(err)
+	assert.Len(lines, 0)
+
+	lines, err = d.Diff(`{"price": 1.00}`, `{"price": 1.01}`)
+	assert.NoError(err)
+	assert.Len(lines, 1)
+}
@@ -0,0 +1,615 @@
+package jf
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// SchemaChange is a change between two versions of JSON Schema
+type SchemaChange struct {
+	// Selector of the change in the schema document, like "properties.id.type"
+	Selector string `json:"selector"`
+	// Breaking is true if data valid for the old schema may be invalid for
+	// the new one
+	Breaking bool `json:"breaking"`
+	// Message describes the change, like "required property \"id\" added"
+	Message string `json:"message"`
+	// A and B are old and new values as JSON, empty if missing
+	A string `json:"a,omitempty"`
+	B string `json:"b,omitempty"`
+}
+
+// tighterIfGreater are keywords, which restrict data more with greater value
+var tighterIfGreater = []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+
+// tighterIfLess are keywords, which restrict data more with lesser value
+var tighterIfLess = []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+
+// schemaComparer walks two schemas in parallel and classifies changes of
+// keywords it understands
+type schemaComparer struct {
+	d       *Differ
+	changes []SchemaChange
+	// covered are selectors of classified changes, raw differences below
+	// them are not reported
+	covered []string
+	// annotations are selectors of keywords like description, changes of
+	// them are not breaking
+	annotations []string
+	// unknown are keywords by selector, which are not classified, so their
+	// changes are assumed to be breaking
+	unknown map[string]string
+}
+
+func (c *schemaComparer) add(selector string, breaking bool, a, b interface{}, format string, args ...interface{}) {
+	ignoreA, ignoreB := c.d.shouldIgnore(selector)
+	if ignoreA || ignoreB {
+		return
+	}
+	change := SchemaChange{
+		Selector: selector,
+		Breaking: breaking,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if a != nil {
+		change.A = toJSONText(a)
+	}
+	if b != nil {
+		change.B = toJSONText(b)
+	}
+	c.changes = append(c.changes, change)
+}
+
+func (c *schemaComparer) cover(selector string) {
+	c.covered = append(c.covered, selector)
+}
+
+// under returns true if selector is parent or the same as selector
+func under(selector, parent string) bool {
+	return selector == parent ||
+		strings.HasPrefix(selector, parent+".") ||
+		strings.HasPrefix(selector, parent+"[")
+}
+
+func (c *schemaComparer) isCovered(selector string) bool {
+	for _, covered := range c.covered {
+		if under(selector, covered) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *schemaComparer) isAnnotation(selector string) bool {
+	for _, annotation := range c.annotations {
+		if under(selector, annotation) {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownKeyword returns unknown keyword, which selector is below
+func (c *schemaComparer) unknownKeyword(selector string) (string, bool) {
+	for parent, keyword := range c.unknown {
+		if under(selector, parent) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// jsonSet returns JSON texts of array items
+func jsonSet(i interface{}) map[string]interface{} {
+	ret := make(map[string]interface{})
+	if list, ok := i.([]interface{}); ok {
+		for _, item := range list {
+			ret[toJSONText(item)] = item
+		}
+	}
+	return ret
+}
+
+// schemaTypes returns allowed types, nil means any type
+func schemaTypes(m objx.Map) map[string]bool {
+	switch t := m["type"].(type) {
+	case string:
+		return map[string]bool{t: true}
+	case []interface{}:
+		ret := make(map[string]bool, len(t))
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				ret[s] = true
+			}
+		}
+		return ret
+	}
+	return nil
+}
+
+// allows returns true if types allow values of type name
+func allows(types map[string]bool, name string) bool {
+	return types == nil || types[name] || (name == "integer" && types["number"])
+}
+
+func (c *schemaComparer) compareTypes(selector string, a, b objx.Map) {
+	typesA, typesB := schemaTypes(a), schemaTypes(b)
+	narrowed, widened := false, false
+	all := []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+	for _, name := range all {
+		if allows(typesA, name) && !allows(typesB, name) {
+			narrowed = true
+		}
+		if !allows(typesA, name) && allows(typesB, name) {
+			widened = true
+		}
+	}
+	selector = joinSelectors(selector, "type")
+	if !narrowed && !widened {
+		if typesA != nil || typesB != nil {
+			c.cover(selector)
+		}
+		return
+	}
+	c.cover(selector)
+	switch {
+	case narrowed && widened:
+		c.add(selector, true, a["type"], b["type"], "type changed")
+	case narrowed:
+		c.add(selector, true, a["type"], b["type"], "type narrowed")
+	default:
+		c.add(selector, false, a["type"], b["type"], "type widened")
+	}
+}
+
+func (c *schemaComparer) compareRequired(selector string, a, b objx.Map) {
+	selector = joinSelectors(selector, "required")
+	c.cover(selector)
+	setA, setB := jsonSet(a["required"]), jsonSet(b["required"])
+	for _, key := range sortedKeys(setB) {
+		if _, found := setA[key]; !found {
+			c.add(selector, true, nil, setB[key], "required property %s added", key)
+		}
+	}
+	for _, key := range sortedKeys(setA) {
+		if _, found := setB[key]; !found {
+			c.add(selector, false, setA[key], nil, "required property %s removed", key)
+		}
+	}
+}
+
+func (c *schemaComparer) compareEnum(selector string, a, b objx.Map) {
+	enumA, hasA := a["enum"]
+	enumB, hasB := b["enum"]
+	selector = joinSelectors(selector, "enum")
+	c.cover(selector)
+	switch {
+	case !hasA && !hasB:
+		return
+	case !hasA:
+		c.add(selector, true, nil, enumB, "enum added")
+		return
+	case !hasB:
+		c.add(selector, false, enumA, nil, "enum removed")
+		return
+	}
+	setA, setB := jsonSet(enumA), jsonSet(enumB)
+	for _, key := range sortedKeys(setA) {
+		if _, found := setB[key]; !found {
+			c.add(selector, true, setA[key], nil, "enum value %s removed", key)
+		}
+	}
+	for _, key := range sortedKeys(setB) {
+		if _, found := setA[key]; !found {
+			c.add(selector, false, nil, setB[key], "enum value %s added", key)
+		}
+	}
+}
+
+func (c *schemaComparer) compareLimit(selector string, a, b objx.Map, keyword string, greaterIsTighter bool) {
+	limitA, hasA := schemaNumber(a, keyword)
+	limitB, hasB := schemaNumber(b, keyword)
+	selector = joinSelectors(selector, keyword)
+	c.cover(selector)
+	switch {
+	case !hasA && !hasB:
+	case !hasA:
+		c.add(selector, true, nil, b[keyword], "%s added", keyword)
+	case !hasB:
+		c.add(selector, false, a[keyword], nil, "%s removed", keyword)
+	case limitA < limitB:
+		c.add(selector, greaterIsTighter, a[keyword], b[keyword], "%s increased", keyword)
+	case limitA > limitB:
+		c.add(selector, !greaterIsTighter, a[keyword], b[keyword], "%s decreased", keyword)
+	}
+}
+
+// allowsAdditional returns false for "additionalProperties": false
+func allowsAdditional(m objx.Map) bool {
+	allowed, ok := m["additionalProperties"].(bool)
+	return !ok || allowed
+}
+
+func (c *schemaComparer) compareProperties(selector string, a, b objx.Map, depth int) {
+	selectorAP := joinSelectors(selector, "additionalProperties")
+	switch {
+	case allowsAdditional(a) && !allowsAdditional(b):
+		c.cover(selectorAP)
+		c.add(selectorAP, true, a["additionalProperties"], b["additionalProperties"], "additional properties not allowed")
+	case !allowsAdditional(a) && allowsAdditional(b):
+		c.cover(selectorAP)
+		c.add(selectorAP, false, a["additionalProperties"], b["additionalProperties"], "additional properties allowed")
+	case allowsAdditional(a) && allowsAdditional(b):
+		c.compareSubschema(selector, a, b, "additionalProperties", depth)
+	}
+
+	propertiesA, _ := a["properties"].(objx.Map)
+	propertiesB, _ := b["properties"].(objx.Map)
+	selector = joinSelectors(selector, "properties")
+	for _, key := range sortedKeys(propertiesA) {
+		keySelector := joinSelectors(selector, key)
+		if _, found := propertiesB[key]; found {
+			c.compare(keySelector, propertiesA[key], propertiesB[key], depth+1)
+			continue
+		}
+		c.cover(keySelector)
+		c.add(keySelector, !allowsAdditional(b), propertiesA[key], nil, "property %q removed", key)
+	}
+	for _, key := range sortedKeys(propertiesB) {
+		if _, found := propertiesA[key]; !found {
+			keySelector := joinSelectors(selector, key)
+			c.cover(keySelector)
+			c.add(keySelector, false, nil, propertiesB[key], "property %q added", key)
+		}
+	}
+}
+
+// compareLists compares subschemas of allOf, anyOf and oneOf by index. An
+// added allOf subschema restricts data, an added anyOf one allows more and an
+// added or removed oneOf subschema may make a value match none or more of
+// them.
+func (c *schemaComparer) compareLists(selector string, a, b objx.Map, depth int) {
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		listA, hasA := a[keyword].([]interface{})
+		listB, hasB := b[keyword].([]interface{})
+		keywordSelector := joinSelectors(selector, keyword)
+		switch {
+		case !hasA && !hasB:
+			continue
+		case !hasA:
+			c.cover(keywordSelector)
+			c.add(keywordSelector, true, nil, b[keyword], "%s added", keyword)
+			continue
+		case !hasB:
+			c.cover(keywordSelector)
+			c.add(keywordSelector, false, a[keyword], nil, "%s removed", keyword)
+			continue
+		}
+		for idx := 0; idx < len(listA) || idx < len(listB); idx++ {
+			itemSelector := joinSelectors(keywordSelector, fmt.Sprintf("[%d]", idx))
+			switch {
+			case idx >= len(listA):
+				c.cover(itemSelector)
+				c.add(itemSelector, keyword != "anyOf", nil, listB[idx], "%s subschema added", keyword)
+			case idx >= len(listB):
+				c.cover(itemSelector)
+				c.add(itemSelector, keyword != "allOf", listA[idx], nil, "%s subschema removed", keyword)
+			default:
+				c.compare(itemSelector, listA[idx], listB[idx], depth+1)
+			}
+		}
+	}
+}
+
+// compareTuple compares prefixItems (or items array of older drafts) by
+// index. An added item schema restricts data, a removed one is breaking only
+// if the rest of array is restricted by items.
+func (c *schemaComparer) compareTuple(selector string, a, b objx.Map, keyword string, depth int) {
+	listA, _ := a[keyword].([]interface{})
+	listB, _ := b[keyword].([]interface{})
+	rest := "items"
+	if keyword == "items" {
+		rest = "additionalItems"
+	}
+	restricted := b[rest] != nil && b[rest] != true
+	keywordSelector := joinSelectors(selector, keyword)
+	for idx := 0; idx < len(listA) || idx < len(listB); idx++ {
+		itemSelector := joinSelectors(keywordSelector, fmt.Sprintf("[%d]", idx))
+		switch {
+		case idx >= len(listA):
+			c.cover(itemSelector)
+			c.add(itemSelector, true, nil, listB[idx], "%s item added", keyword)
+		case idx >= len(listB):
+			c.cover(itemSelector)
+			c.add(itemSelector, restricted, listA[idx], nil, "%s item removed", keyword)
+		default:
+			c.compare(itemSelector, listA[idx], listB[idx], depth+1)
+		}
+	}
+}
+
+// compareSubschema compares a subschema of keyword like items, then or else,
+// an added subschema restricts data
+func (c *schemaComparer) compareSubschema(selector string, a, b objx.Map, keyword string, depth int) {
+	subA, hasA := a[keyword]
+	subB, hasB := b[keyword]
+	selector = joinSelectors(selector, keyword)
+	switch {
+	case !hasA && !hasB:
+	case !hasA:
+		c.cover(selector)
+		c.add(selector, subB != true, nil, subB, "%s added", keyword)
+	case !hasB:
+		c.cover(selector)
+		c.add(selector, false, subA, nil, "%s removed", keyword)
+	default:
+		c.compare(selector, subA, subB, depth+1)
+	}
+}
+
+// compareItems compares items, which is a subschema or an array of them in
+// drafts before 2020-12
+func (c *schemaComparer) compareItems(selector string, a, b objx.Map, depth int) {
+	_, tupleA := a["items"].([]interface{})
+	_, tupleB := b["items"].([]interface{})
+	switch {
+	case tupleA && tupleB:
+		c.compareTuple(selector, a, b, "items", depth)
+	case tupleA || tupleB:
+		selector = joinSelectors(selector, "items")
+		c.cover(selector)
+		c.add(selector, true, a["items"], b["items"], "items changed")
+	default:
+		c.compareSubschema(selector, a, b, "items", depth)
+	}
+}
+
+// compareExact compares keywords like pattern or $ref, which can't be
+// compared by meaning, so any added or changed value is breaking
+func (c *schemaComparer) compareExact(selector string, a, b objx.Map, keyword string) {
+	valueA, hasA := a[keyword]
+	valueB, hasB := b[keyword]
+	selector = joinSelectors(selector, keyword)
+	c.cover(selector)
+	switch {
+	case !hasA && !hasB:
+	case !hasA:
+		c.add(selector, true, nil, valueB, "%s added", keyword)
+	case !hasB:
+		c.add(selector, false, valueA, nil, "%s removed", keyword)
+	case toJSONText(valueA) != toJSONText(valueB):
+		c.add(selector, true, valueA, valueB, "%s changed", keyword)
+	}
+}
+
+// compareMultipleOf compares multipleOf, a change is not breaking if the old
+// value is a multiple of the new one
+func (c *schemaComparer) compareMultipleOf(selector string, a, b objx.Map) {
+	nA, hasA := schemaNumber(a, "multipleOf")
+	nB, hasB := schemaNumber(b, "multipleOf")
+	selector = joinSelectors(selector, "multipleOf")
+	c.cover(selector)
+	switch {
+	case !hasA && !hasB:
+	case !hasA:
+		c.add(selector, true, nil, b["multipleOf"], "multipleOf added")
+	case !hasB:
+		c.add(selector, false, a["multipleOf"], nil, "multipleOf removed")
+	case nA != nB:
+		ratio := nA / nB
+		c.add(selector, math.Abs(ratio-math.Round(ratio)) > 1e-9, a["multipleOf"], b["multipleOf"], "multipleOf changed")
+	}
+}
+
+func (c *schemaComparer) compareUniqueItems(selector string, a, b objx.Map) {
+	selector = joinSelectors(selector, "uniqueItems")
+	c.cover(selector)
+	uniqueA, uniqueB := a["uniqueItems"] == true, b["uniqueItems"] == true
+	switch {
+	case !uniqueA && uniqueB:
+		c.add(selector, true, a["uniqueItems"], b["uniqueItems"], "unique items required")
+	case uniqueA && !uniqueB:
+		c.add(selector, false, a["uniqueItems"], b["uniqueItems"], "unique items not required")
+	}
+}
+
+func (c *schemaComparer) compareDependentRequired(selector string, a, b objx.Map) {
+	depsA, _ := a["dependentRequired"].(objx.Map)
+	depsB, _ := b["dependentRequired"].(objx.Map)
+	selector = joinSelectors(selector, "dependentRequired")
+	c.cover(selector)
+	keys := make(map[string]interface{}, len(depsA)+len(depsB))
+	for key := range depsA {
+		keys[key] = nil
+	}
+	for key := range depsB {
+		keys[key] = nil
+	}
+	for _, key := range sortedKeys(keys) {
+		keySelector := joinSelectors(selector, key)
+		setA, setB := jsonSet(depsA[key]), jsonSet(depsB[key])
+		for _, name := range sortedKeys(setB) {
+			if _, found := setA[name]; !found {
+				c.add(keySelector, true, nil, setB[name], "property %s required with %q", name, key)
+			}
+		}
+		for _, name := range sortedKeys(setA) {
+			if _, found := setB[name]; !found {
+				c.add(keySelector, false, setA[name], nil, "property %s not required with %q", name, key)
+			}
+		}
+	}
+}
+
+// compareDefs compares definitions of $defs or definitions, a removed one
+// breaks $ref to it
+func (c *schemaComparer) compareDefs(selector string, a, b objx.Map, depth int) {
+	for _, keyword := range []string{"$defs", "definitions"} {
+		defsA, _ := a[keyword].(objx.Map)
+		defsB, _ := b[keyword].(objx.Map)
+		keywordSelector := joinSelectors(selector, keyword)
+		for _, key := range sortedKeys(defsA) {
+			keySelector := joinSelectors(keywordSelector, key)
+			if defB, found := defsB[key]; found {
+				c.compare(keySelector, defsA[key], defB, depth+1)
+				continue
+			}
+			c.cover(keySelector)
+			c.add(keySelector, true, defsA[key], nil, "definition %q removed", key)
+		}
+		for _, key := range sortedKeys(defsB) {
+			if _, found := defsA[key]; !found {
+				keySelector := joinSelectors(keywordSelector, key)
+				c.cover(keySelector)
+				c.add(keySelector, false, nil, defsB[key], "definition %q added", key)
+			}
+		}
+	}
+}
+
+// classifiedKeywords are keywords compared by schemaComparer
+var classifiedKeywords = map[string]bool{
+	"type": true, "required": true, "enum": true, "properties": true, "additionalProperties": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "prefixItems": true, "items": true, "additionalItems": true,
+	"not": true, "$defs": true, "definitions": true, "pattern": true, "const": true, "format": true,
+	"$ref": true, "if": true, "then": true, "else": true, "multipleOf": true, "uniqueItems": true,
+	"dependentRequired": true,
+}
+
+// annotationKeywords do not affect validation, so changes of them are not
+// breaking. So are extensions starting with "x-".
+var annotationKeywords = map[string]bool{
+	"title": true, "description": true, "$comment": true, "examples": true, "default": true,
+	"deprecated": true, "readOnly": true, "writeOnly": true, "$schema": true, "$id": true,
+}
+
+// register remembers selectors of annotations and unknown keywords
+func (c *schemaComparer) register(selector string, a, b objx.Map) {
+	for _, m := range []objx.Map{a, b} {
+		for keyword := range m {
+			keywordSelector := joinSelectors(selector, keyword)
+			switch {
+			case classifiedKeywords[keyword]:
+			case annotationKeywords[keyword] || strings.HasPrefix(keyword, "x-"):
+				c.annotations = append(c.annotations, keywordSelector)
+			default:
+				c.unknown[keywordSelector] = keyword
+			}
+		}
+	}
+}
+
+// schemaMap returns schema object, true schema is an empty one
+func schemaMap(schema interface{}) (objx.Map, bool) {
+	if schema == true {
+		return objx.Map{}, true
+	}
+	m, ok := schema.(objx.Map)
+	return m, ok
+}
+
+func (c *schemaComparer) compare(selector string, a, b interface{}, depth int) {
+	if depth > maxSchemaDepth {
+		return
+	}
+	if b == false && a != false {
+		c.cover(selector)
+		c.add(selector, true, a, b, "schema does not allow any value")
+		return
+	}
+	if a == false && b != false {
+		c.cover(selector)
+		c.add(selector, false, a, b, "schema allows values")
+		return
+	}
+	mA, okA := schemaMap(a)
+	mB, okB := schemaMap(b)
+	if !okA || !okB {
+		// let Differ to report the rest
+		return
+	}
+	if a == true || b == true {
+		// Differ reports the whole schema, keywords are classified below
+		c.cover(selector)
+	}
+
+	c.compareTypes(selector, mA, mB)
+	c.compareRequired(selector, mA, mB)
+	c.compareEnum(selector, mA, mB)
+	for _, keyword := range tighterIfGreater {
+		c.compareLimit(selector, mA, mB, keyword, true)
+	}
+	for _, keyword := range tighterIfLess {
+		c.compareLimit(selector, mA, mB, keyword, false)
+	}
+	c.compareProperties(selector, mA, mB, depth)
+	c.compareLists(selector, mA, mB, depth)
+	c.compareTuple(selector, mA, mB, "prefixItems", depth)
+	c.compareItems(selector, mA, mB, depth)
+	for _, keyword := range []string{"then", "else", "additionalItems"} {
+		c.compareSubschema(selector, mA, mB, keyword, depth)
+	}
+	// not and if invert the meaning of subschema
+	for _, keyword := range []string{"pattern", "const", "format", "$ref", "if", "not"} {
+		c.compareExact(selector, mA, mB, keyword)
+	}
+	c.compareMultipleOf(selector, mA, mB)
+	c.compareUniqueItems(selector, mA, mB)
+	c.compareDependentRequired(selector, mA, mB)
+	c.compareDefs(selector, mA, mB, depth)
+	c.register(selector, mA, mB)
+}
+
+// DiffSchemas compares two versions of JSON Schema and classifies each change
+// as breaking or not. A change is breaking if data valid for schemaA may be
+// invalid for schemaB, like added required property, narrowed type, removed
+// enum value or a tighter limit. Changes of annotations, like description,
+// are not breaking. Changes of keywords, which are not classified, are
+// assumed to be breaking. Rules of Differ apply to the schema documents, so
+// volatile parts can be ignored.
+func (d *Differ) DiffSchemas(schemaA, schemaB string) ([]SchemaChange, error) {
+	d2 := d.Clone()
+	d2.schema = nil
+	// all differences are needed to classify them
+	d2.maxDiffs = 0
+	// order of these arrays does not matter
+	d2.AddIgnoreOrder(regexp.MustCompile(`(^|\.)(required|enum|type)$`))
+	diff, err := d2.Diff(schemaA, schemaB)
+	if err != nil {
+		return nil, err
+	}
+	docA, _ := parseDocument(schemaA)
+	docB, _ := parseDocument(schemaB)
+
+	c := &schemaComparer{d: d, unknown: make(map[string]string)}
+	c.compare("", docA.root, docB.root, 0)
+	for _, p := range diff {
+		if c.isCovered(p.selector) {
+			continue
+		}
+		change := SchemaChange{
+			Selector: p.selector,
+			Message:  p.kind.String(),
+			A:        p.valueA,
+			B:        p.valueB,
+		}
+		if !c.isAnnotation(p.selector) {
+			change.Breaking = true
+			if keyword, found := c.unknownKeyword(p.selector); found {
+				change.Message = fmt.Sprintf("unknown keyword %s %s, assumed breaking", keyword, p.kind)
+			} else {
+				change.Message = fmt.Sprintf("%s, assumed breaking", p.kind)
+			}
+		}
+		c.changes = append(c.changes, change)
+	}
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		return c.changes[i].Selector < c.changes[j].Selector
+	})
+	return c.changes, nil
+}
@@ -0,0 +1,198 @@
+package jf
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchemaV1 = `{
+	"type": "object",
+	"required": ["id"],
+	"properties": {
+		"id": {"type": ["integer", "string"]},
+		"name": {"type": "string", "description": "user name", "maxLength": 10},
+		"status": {"enum": ["new", "done"]},
+		"age": {"type": "integer"},
+		"nick": {"type": "string"}
+	},
+	"$defs": {
+		"tag": {"type": "string", "minLength": 1}
+	}
+}`
+
+const testSchemaV2 = `{
+	"type": "object",
+	"required": ["name", "id"],
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string", "description": "full user name", "maxLength": 20},
+		"status": {"enum": ["done", "archived"]},
+		"age": {"type": "number"},
+		"email": {"type": "string"}
+	},
+	"$defs": {
+		"tag": {"type": "string", "minLength": 2}
+	}
+}`
+
+func TestDiffSchemas(t *testing.T) {
+	changes, err := NewDiffer().DiffSchemas(testSchemaV1, testSchemaV2)
+	assert.NoError(t, err)
+	assert.Equal(t, []SchemaChange{
+		{Selector: "$defs.tag.minLength", Breaking: true, Message: "minLength increased", A: "1", B: "2"},
+		{Selector: "properties.age.type", Breaking: false, Message: "type widened", A: `"integer"`, B: `"number"`},
+		{Selector: "properties.email", Breaking: false, Message: `property "email" added`, B: `{"type":"string"}`},
+		{Selector: "properties.id.type", Breaking: true, Message: "type narrowed", A: `["integer","string"]`, B: `"integer"`},
+		{Selector: "properties.name.description", Breaking: false, Message: "changed", A: `"user name"`, B: `"full user name"`},
+		{Selector: "properties.name.maxLength", Breaking: false, Message: "maxLength increased", A: "10", B: "20"},
+		{Selector: "properties.nick", Breaking: false, Message: `property "nick" removed`, A: `{"type":"string"}`},
+		{Selector: "properties.status.enum", Breaking: true, Message: `enum value "new" removed`, A: `"new"`},
+		{Selector: "properties.status.enum", Breaking: false, Message: `enum value "archived" added`, B: `"archived"`},
+		{Selector: "required", Breaking: true, Message: `required property "name" added`, B: `"name"`},
+	}, changes)
+
+	// reordered arrays are not a change, ignore rules apply
+	changes, err = NewDiffer().
+		AddIgnore(RuleAB, regexp.MustCompile(`description$`)).
+		DiffSchemas(testSchemaV1, testSchemaV1)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = NewDiffer().
+		AddIgnore(RuleAB, regexp.MustCompile(`description$`)).
+		DiffSchemas(
+			`{"required": ["a", "b"], "properties": {"a": {"description": "x"}}}`,
+			`{"required": ["b", "a"], "properties": {"a": {"description": "y"}}, "additionalProperties": false}`,
+		)
+	assert.NoError(t, err)
+	assert.Equal(t, []SchemaChange{
+		{Selector: "additionalProperties", Breaking: true, Message: "additional properties not allowed", B: "false"},
+	}, changes)
+
+	_, err = NewDiffer().DiffSchemas(`{`, `{}`)
+	assert.Error(t, err)
+}
+
+func TestDiffSchemasKeywords(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected []SchemaChange
+	}{
+		{"pattern added", `{}`, `{"pattern": "^a"}`, []SchemaChange{
+			{Selector: "pattern", Breaking: true, Message: "pattern added", B: `"^a"`}}},
+		{"pattern changed", `{"pattern": "^a"}`, `{"pattern": "^b"}`, []SchemaChange{
+			{Selector: "pattern", Breaking: true, Message: "pattern changed", A: `"^a"`, B: `"^b"`}}},
+		{"pattern removed", `{"pattern": "^a"}`, `{}`, []SchemaChange{
+			{Selector: "pattern", Breaking: false, Message: "pattern removed", A: `"^a"`}}},
+		{"const added", `{}`, `{"const": 1}`, []SchemaChange{
+			{Selector: "const", Breaking: true, Message: "const added", B: "1"}}},
+		{"const changed", `{"const": {"a": 1}}`, `{"const": {"a": 2}}`, []SchemaChange{
+			{Selector: "const", Breaking: true, Message: "const changed", A: `{"a":1}`, B: `{"a":2}`}}},
+		{"const removed", `{"const": 1}`, `{}`, []SchemaChange{
+			{Selector: "const", Breaking: false, Message: "const removed", A: "1"}}},
+		{"multipleOf added", `{}`, `{"multipleOf": 2}`, []SchemaChange{
+			{Selector: "multipleOf", Breaking: true, Message: "multipleOf added", B: "2"}}},
+		{"multipleOf coarser", `{"multipleOf": 2}`, `{"multipleOf": 4}`, []SchemaChange{
+			{Selector: "multipleOf", Breaking: true, Message: "multipleOf changed", A: "2", B: "4"}}},
+		{"multipleOf finer", `{"multipleOf": 0.3}`, `{"multipleOf": 0.1}`, []SchemaChange{
+			{Selector: "multipleOf", Breaking: false, Message: "multipleOf changed", A: "0.3", B: "0.1"}}},
+		{"multipleOf removed", `{"multipleOf": 2}`, `{}`, []SchemaChange{
+			{Selector: "multipleOf", Breaking: false, Message: "multipleOf removed", A: "2"}}},
+		{"uniqueItems added", `{}`, `{"uniqueItems": true}`, []SchemaChange{
+			{Selector: "uniqueItems", Breaking: true, Message: "unique items required", B: "true"}}},
+		{"uniqueItems removed", `{"uniqueItems": true}`, `{"uniqueItems": false}`, []SchemaChange{
+			{Selector: "uniqueItems", Breaking: false, Message: "unique items not required", A: "true", B: "false"}}},
+		{"items added", `{"type": "array"}`, `{"type": "array", "items": {"type": "string"}}`, []SchemaChange{
+			{Selector: "items", Breaking: true, Message: "items added", B: `{"type":"string"}`}}},
+		{"items type changed", `{"items": {"type": "string"}}`, `{"items": {"type": "integer"}}`, []SchemaChange{
+			{Selector: "items.type", Breaking: true, Message: "type changed", A: `"string"`, B: `"integer"`}}},
+		{"items type added", `{"items": true}`, `{"items": {"type": "integer"}}`, []SchemaChange{
+			{Selector: "items.type", Breaking: true, Message: "type narrowed", B: `"integer"`}}},
+		{"items removed", `{"items": {"type": "string"}}`, `{}`, []SchemaChange{
+			{Selector: "items", Breaking: false, Message: "items removed", A: `{"type":"string"}`}}},
+		{"prefixItems added", `{"prefixItems": [{"type": "string"}]}`, `{"prefixItems": [{"type": "string"}, {"type": "integer"}]}`, []SchemaChange{
+			{Selector: "prefixItems[1]", Breaking: true, Message: "prefixItems item added", B: `{"type":"integer"}`}}},
+		{"prefixItems removed", `{"prefixItems": [{"type": "string"}, {"type": "integer"}]}`, `{"prefixItems": [{"type": "string"}]}`, []SchemaChange{
+			{Selector: "prefixItems[1]", Breaking: false, Message: "prefixItems item removed", A: `{"type":"integer"}`}}},
+		{"prefixItems removed with items", `{"prefixItems": [{}, {}], "items": false}`, `{"prefixItems": [{}], "items": false}`, []SchemaChange{
+			{Selector: "prefixItems[1]", Breaking: true, Message: "prefixItems item removed", A: `{}`}}},
+		{"prefixItems narrowed", `{"prefixItems": [{"type": ["string", "null"]}]}`, `{"prefixItems": [{"type": "string"}]}`, []SchemaChange{
+			{Selector: "prefixItems[0].type", Breaking: true, Message: "type narrowed", A: `["string","null"]`, B: `"string"`}}},
+		{"$ref changed", `{"$ref": "#/$defs/a"}`, `{"$ref": "#/$defs/b"}`, []SchemaChange{
+			{Selector: "$ref", Breaking: true, Message: "$ref changed", A: `"#/$defs/a"`, B: `"#/$defs/b"`}}},
+		{"$ref removed", `{"$ref": "#/$defs/a"}`, `{}`, []SchemaChange{
+			{Selector: "$ref", Breaking: false, Message: "$ref removed", A: `"#/$defs/a"`}}},
+		{"format added", `{"type": "string"}`, `{"type": "string", "format": "email"}`, []SchemaChange{
+			{Selector: "format", Breaking: true, Message: "format added", B: `"email"`}}},
+		{"format changed", `{"format": "email"}`, `{"format": "uri"}`, []SchemaChange{
+			{Selector: "format", Breaking: true, Message: "format changed", A: `"email"`, B: `"uri"`}}},
+		{"if added", `{}`, `{"if": {"required": ["a"]}, "then": {"required": ["b"]}}`, []SchemaChange{
+			{Selector: "if", Breaking: true, Message: "if added", B: `{"required":["a"]}`},
+			{Selector: "then", Breaking: true, Message: "then added", B: `{"required":["b"]}`}}},
+		{"if changed", `{"if": {"required": ["a"]}}`, `{"if": {"required": ["b"]}}`, []SchemaChange{
+			{Selector: "if", Breaking: true, Message: "if changed", A: `{"required":["a"]}`, B: `{"required":["b"]}`}}},
+		{"then tightened", `{"if": {}, "then": {"maxLength": 3}}`, `{"if": {}, "then": {"maxLength": 2}}`, []SchemaChange{
+			{Selector: "then.maxLength", Breaking: true, Message: "maxLength decreased", A: "3", B: "2"}}},
+		{"else removed", `{"if": {}, "else": {"maxLength": 3}}`, `{"if": {}}`, []SchemaChange{
+			{Selector: "else", Breaking: false, Message: "else removed", A: `{"maxLength":3}`}}},
+		{"not changed", `{"not": {"type": "string"}}`, `{"not": {"type": ["string", "null"]}}`, []SchemaChange{
+			{Selector: "not", Breaking: true, Message: "not changed", A: `{"type":"string"}`, B: `{"type":["string","null"]}`}}},
+		{"dependentRequired added", `{}`, `{"dependentRequired": {"a": ["b"]}}`, []SchemaChange{
+			{Selector: "dependentRequired.a", Breaking: true, Message: `property "b" required with "a"`, B: `"b"`}}},
+		{"dependentRequired changed", `{"dependentRequired": {"a": ["b", "c"]}}`, `{"dependentRequired": {"a": ["c", "d"]}}`, []SchemaChange{
+			{Selector: "dependentRequired.a", Breaking: true, Message: `property "d" required with "a"`, B: `"d"`},
+			{Selector: "dependentRequired.a", Breaking: false, Message: `property "b" not required with "a"`, A: `"b"`}}},
+		{"minItems increased", `{"minItems": 1}`, `{"minItems": 2}`, []SchemaChange{
+			{Selector: "minItems", Breaking: true, Message: "minItems increased", A: "1", B: "2"}}},
+		{"maxItems added", `{}`, `{"maxItems": 2}`, []SchemaChange{
+			{Selector: "maxItems", Breaking: true, Message: "maxItems added", B: "2"}}},
+		{"maxItems increased", `{"maxItems": 1}`, `{"maxItems": 2}`, []SchemaChange{
+			{Selector: "maxItems", Breaking: false, Message: "maxItems increased", A: "1", B: "2"}}},
+		{"minProperties removed", `{"minProperties": 1}`, `{}`, []SchemaChange{
+			{Selector: "minProperties", Breaking: false, Message: "minProperties removed", A: "1"}}},
+		{"maxProperties decreased", `{"maxProperties": 3}`, `{"maxProperties": 2}`, []SchemaChange{
+			{Selector: "maxProperties", Breaking: true, Message: "maxProperties decreased", A: "3", B: "2"}}},
+		{"allOf subschema added", `{"allOf": [{}]}`, `{"allOf": [{}, {"required": ["a"]}]}`, []SchemaChange{
+			{Selector: "allOf[1]", Breaking: true, Message: "allOf subschema added", B: `{"required":["a"]}`}}},
+		{"anyOf subschema added", `{"anyOf": [{"type": "string"}]}`, `{"anyOf": [{"type": "string"}, {"type": "null"}]}`, []SchemaChange{
+			{Selector: "anyOf[1]", Breaking: false, Message: "anyOf subschema added", B: `{"type":"null"}`}}},
+		{"anyOf subschema removed", `{"anyOf": [{"type": "string"}, {"type": "null"}]}`, `{"anyOf": [{"type": "string"}]}`, []SchemaChange{
+			{Selector: "anyOf[1]", Breaking: true, Message: "anyOf subschema removed", A: `{"type":"null"}`}}},
+		{"additionalProperties schema", `{"additionalProperties": {"type": ["string", "null"]}}`, `{"additionalProperties": {"type": "string"}}`, []SchemaChange{
+			{Selector: "additionalProperties.type", Breaking: true, Message: "type narrowed", A: `["string","null"]`, B: `"string"`}}},
+		{"definition added", `{"$defs": {}}`, `{"$defs": {"a": {}}}`, []SchemaChange{
+			{Selector: "$defs.a", Breaking: false, Message: `definition "a" added`, B: `{}`}}},
+		{"definition removed", `{"definitions": {"a": {}}}`, `{"definitions": {}}`, []SchemaChange{
+			{Selector: "definitions.a", Breaking: true, Message: `definition "a" removed`, A: `{}`}}},
+		{"annotations", `{"title": "a", "examples": [1], "x-owner": "a"}`, `{"title": "b", "examples": [2], "x-owner": "b"}`, []SchemaChange{
+			{Selector: "examples[0]", Breaking: false, Message: "changed", A: "1", B: "2"},
+			{Selector: "title", Breaking: false, Message: "changed", A: `"a"`, B: `"b"`},
+			{Selector: "x-owner", Breaking: false, Message: "changed", A: `"a"`, B: `"b"`}}},
+		{"unknown keyword", `{"contains": {"type": "string"}}`, `{"contains": {"type": "integer"}}`, []SchemaChange{
+			{Selector: "contains.type", Breaking: true, Message: "unknown keyword contains changed, assumed breaking", A: `"string"`, B: `"integer"`}}},
+		{"unknown keyword added", `{}`, `{"minContains": 2}`, []SchemaChange{
+			{Selector: "minContains", Breaking: true, Message: "unknown keyword minContains added, assumed breaking", B: "2"}}},
+		{"schema true", `{"properties": {"a": true}}`, `{"properties": {"a": {"type": "string"}}}`, []SchemaChange{
+			{Selector: "properties.a.type", Breaking: true, Message: "type narrowed", B: `"string"`}}},
+		{"schema false", `{"properties": {"a": false}}`, `{"properties": {"a": {}}}`, []SchemaChange{
+			{Selector: "properties.a", Breaking: false, Message: "schema allows values", A: "false", B: "{}"}}},
+	}
+
+	for _, tc := range testCases {
+		changes, err := NewDiffer().DiffSchemas(tc.a, tc.b)
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, changes, tc.name)
+	}
+}
+
+func TestDiffSchemasMaxDiffs(t *testing.T) {
+	d := NewDiffer().SetMaxDiffs(1)
+	changes, err := d.DiffSchemas(`{"title": "a", "contains": {}}`, `{"title": "b", "contains": {"type": "string"}}`)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.True(t, changes[0].Breaking)
+	assert.Len(t, d.Rules(), 0)
+}